@@ -6,10 +6,10 @@ import (
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/dependency"
 )
 
-func build(ctx stdcontext.Context, pushImages bool) error {
+func build(ctx stdcontext.Context, context string, pushImages bool) error {
 	dependencyContainer, err := dependency.ContainerFromContext(ctx)
 	if err != nil {
 		return err
 	}
-	return dependencyContainer.Platform().Build(ctx, pushImages)
+	return dependencyContainer.Platform().Build(ctx, context, pushImages)
 }
@@ -0,0 +1,15 @@
+package main
+
+import (
+	stdcontext "context"
+
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/dependency"
+)
+
+func pruneCache(ctx stdcontext.Context, all bool) error {
+	dependencyContainer, err := dependency.ContainerFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return dependencyContainer.Platform().PruneCache(all)
+}
@@ -0,0 +1,15 @@
+package main
+
+import (
+	stdcontext "context"
+
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/dependency"
+)
+
+func execCommand(ctx stdcontext.Context, repositories []string, parallel int, executable string, args []string) error {
+	dependencyContainer, err := dependency.ContainerFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return dependencyContainer.Platform().Exec(ctx, repositories, parallel, executable, args)
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/dependency"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/history"
+)
+
+const historyFile = ".platform/history.jsonl"
+
+type historyRun struct {
+	id        string
+	start     time.Time
+	command   string
+	context   string
+	dryRun    bool
+	container dependency.Container
+}
+
+// record appends finished run to history, runs failed before dependency container is created
+// are recorded without repositories and pushed images
+func (run historyRun) record(ctx context.Context, err error) error {
+	if _, ok := readOnlyCommands[run.command]; ok {
+		return nil
+	}
+	entry := history.Entry{
+		ID:      run.id,
+		Command: run.command,
+		Args:    os.Args[1:],
+		Context: run.context,
+		User:    currentUser(),
+		Start:   run.start,
+		End:     time.Now(),
+		Status:  history.StatusSuccess,
+		DryRun:  run.dryRun,
+	}
+	switch {
+	case err == nil:
+	case ctx.Err() != nil || errors.Is(err, command.ErrInterrupted):
+		entry.Status = history.StatusInterrupted
+		entry.Error = err.Error()
+	default:
+		entry.Status = history.StatusFailed
+		entry.Error = err.Error()
+	}
+	if run.container != nil {
+		entry = run.container.History().Complete(entry)
+	}
+	return history.Append(historyFile, entry)
+}
+
+func currentUser() string {
+	u, err := user.Current()
+	if err == nil && u.Username != "" {
+		return u.Username
+	}
+	return os.Getenv("USER")
+}
+
+// listHistory prints runs matching filter, limit keeps only last runs
+func listHistory(filter history.Filter, limit int) error {
+	entries, err := history.Load(historyFile)
+	if err != nil {
+		return err
+	}
+	var matched []history.Entry
+	for _, entry := range entries {
+		if filter.Match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	if limit > 0 && len(matched) > limit {
+		matched = matched[len(matched)-limit:]
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tCOMMAND\tCONTEXT\tUSER\tSTART\tDURATION\tSTATUS")
+	for _, entry := range matched {
+		status := entry.Status
+		if entry.DryRun {
+			status += " (dry-run)"
+		}
+		_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			entry.ID,
+			entry.Command,
+			entry.Context,
+			entry.User,
+			entry.Start.Local().Format(time.DateTime),
+			entry.End.Sub(entry.Start).Round(time.Millisecond),
+			status,
+		)
+	}
+	return w.Flush()
+}
+
+func showHistory(id string) error {
+	entries, err := history.Load(historyFile)
+	if err != nil {
+		return err
+	}
+	entry, err := history.Find(entries, id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("run:      %v\n", entry.ID)
+	fmt.Printf("command:  platform %v\n", strings.Join(entry.Args, " "))
+	fmt.Printf("context:  %v\n", entry.Context)
+	fmt.Printf("user:     %v\n", entry.User)
+	fmt.Printf("start:    %v\n", entry.Start.Local().Format(time.DateTime))
+	fmt.Printf("duration: %v\n", entry.End.Sub(entry.Start).Round(time.Millisecond))
+	fmt.Printf("status:   %v\n", entry.Status)
+	if entry.DryRun {
+		fmt.Println("dry-run:  true")
+	}
+	if entry.Error != "" {
+		fmt.Printf("error:    %v\n", entry.Error)
+	}
+	if len(entry.Repositories) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "REPOSITORY\tBRANCH\tCOMMIT\tHASH")
+		for _, id := range sortedRepositories(entry) {
+			repository := entry.Repositories[id]
+			_, _ = fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", id, repository.Branch, repository.Commit, repository.Hash)
+		}
+		err = w.Flush()
+		if err != nil {
+			return err
+		}
+	}
+	if len(entry.Pushed) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "REPOSITORY\tPUSHED\tDIGEST")
+		for _, image := range entry.Pushed {
+			_, _ = fmt.Fprintf(w, "%v\t%v\t%v\n", image.Repository, image.Reference, image.Digest)
+		}
+		return w.Flush()
+	}
+	return nil
+}
+
+// diffHistory prints repositories whose commit, hash or branch differ between runs
+func diffHistory(fromID, toID string) error {
+	entries, err := history.Load(historyFile)
+	if err != nil {
+		return err
+	}
+	from, err := history.Find(entries, fromID)
+	if err != nil {
+		return err
+	}
+	to, err := history.Find(entries, toID)
+	if err != nil {
+		return err
+	}
+	changes := history.Diff(from, to)
+	if len(changes) == 0 {
+		fmt.Printf("repositories of runs %v and %v are equal\n", from.ID, to.ID)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "REPOSITORY\t%v\t%v\n", from.ID, to.ID)
+	for _, change := range changes {
+		_, _ = fmt.Fprintf(w, "%v\t%v\t%v\n", change.Repository, formatState(change.From), formatState(change.To))
+	}
+	return w.Flush()
+}
+
+func formatState(repository *history.Repository) string {
+	if repository == nil {
+		return "-"
+	}
+	commit := repository.Commit
+	if len(commit) > 12 {
+		commit = commit[:12]
+	}
+	hash := repository.Hash
+	if len(hash) > 12 {
+		hash = hash[:12]
+	}
+	return fmt.Sprintf("%v %v hash %v", repository.Branch, commit, hash)
+}
+
+func sortedRepositories(entry history.Entry) []string {
+	ids := make([]string, 0, len(entry.Repositories))
+	for id := range entry.Repositories {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
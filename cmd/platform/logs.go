@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+const (
+	runLogDir = ".platform/logs"
+	// followInterval is delay between checks of followed logs for new output
+	followInterval = 500 * time.Millisecond
+)
+
+// printLogs prints logs of run, tail limits output to last lines of every log,
+// follow keeps printing output appended to logs and new logs of run until ctx is cancelled
+func printLogs(ctx context.Context, runID string, repository string, tail int, follow bool) error {
+	if runID == "" {
+		runID = command.LatestRun
+	}
+	if runID == command.LatestRun {
+		// followed run stays the same when next run relinks latest
+		latest, err := os.Readlink(filepath.Join(runLogDir, command.LatestRun))
+		if err != nil {
+			return fmt.Errorf("no logged runs: %w", err)
+		}
+		runID = latest
+	}
+	files, err := command.LogFiles(runLogDir, runID, repository)
+	if err != nil {
+		return err
+	}
+	offsets := make(map[string]int64, len(files))
+	for i, file := range files {
+		if i > 0 {
+			fmt.Println()
+		}
+		printLogHeader(file)
+		offsets[file], err = printLog(file, tail)
+		if err != nil {
+			return err
+		}
+	}
+	if !follow {
+		return nil
+	}
+	current := ""
+	if len(files) > 0 {
+		current = files[len(files)-1]
+	}
+	return followLogs(ctx, runID, repository, offsets, current)
+}
+
+func printLogHeader(file string) {
+	fmt.Printf("==> %v <==\n", strings.TrimPrefix(file, runLogDir+"/"))
+}
+
+// printLog prints log or its last tail lines and returns printed size, lines are not limited in length
+func printLog(file string, tail int) (int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	if tail <= 0 {
+		return io.Copy(os.Stdout, f)
+	}
+	var (
+		lines  []string
+		offset int64
+	)
+	reader := bufio.NewReader(f)
+	for {
+		line, err2 := reader.ReadString('\n')
+		offset += int64(len(line))
+		if line != "" {
+			lines = append(lines, line)
+			if len(lines) > tail {
+				lines = lines[1:]
+			}
+		}
+		if errors.Is(err2, io.EOF) {
+			break
+		}
+		if err2 != nil {
+			return offset, err2
+		}
+	}
+	for _, line := range lines {
+		fmt.Print(line)
+	}
+	return offset, nil
+}
+
+// followLogs prints output appended to logs after offsets, header is printed when output switches to another log
+func followLogs(ctx context.Context, runID string, repository string, offsets map[string]int64, current string) error {
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		files, err := command.LogFiles(runLogDir, runID, repository)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			info, err2 := os.Stat(file)
+			if err2 != nil {
+				return err2
+			}
+			if info.Size() <= offsets[file] {
+				continue
+			}
+			if file != current {
+				fmt.Println()
+				printLogHeader(file)
+				current = file
+			}
+			offsets[file], err2 = printFrom(file, offsets[file])
+			if err2 != nil {
+				return err2
+			}
+		}
+	}
+}
+
+// printFrom prints log from offset and returns new offset
+func printFrom(file string, offset int64) (int64, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return offset, err
+	}
+	defer f.Close()
+	_, err = f.Seek(offset, io.SeekStart)
+	if err != nil {
+		return offset, err
+	}
+	n, err := io.Copy(os.Stdout, f)
+	return offset + n, err
+}
+
+func listRuns() error {
+	runs, err := command.Runs(runLogDir)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		fmt.Println(run)
+	}
+	return nil
+}
@@ -2,45 +2,116 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/config/platformconfig"
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/dependency"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/history"
 
 	"github.com/urfave/cli/v2"
 )
 
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+)
+
 func main() {
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	defer cancelFunc()
 	ctx = listenOSKillSignalsContext(ctx)
 	mainLogger := logger.NewTextLogger()
+	run := historyRun{id: command.NewRunID(), start: time.Now()}
 
 	platformConfig, err := platformconfig.Load("platform.json")
 	if err != nil {
 		mainLogger.FatalError(err, "failed load platform config")
 	}
-	container := dependency.NewDependencyContainer(mainLogger, platformConfig, os.Getenv("SILENT") != "")
-	ctx = dependency.ContainerToContext(ctx, container)
 
 	app := &cli.App{
 		Name: "platform",
 		Flags: []cli.Flag{
 			&cli.StringFlag{
-				Name:     "context",
-				Required: true,
+				Name:  "context",
+				Usage: "platform context, required by commands checking out, building and pushing repositories",
+			},
+			&cli.StringFlag{
+				Name:  "image-builder",
+				Usage: "image builder backend: docker, docker-buildx, podman or buildah",
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "log format: text or json, json logs also contain events of checkouts, steps, pushes and pipelines",
+				Value: logFormatText,
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print git, docker and pipeline commands instead of running them, read-only git queries are executed",
+			},
+			&cli.StringFlag{
+				Name:   "record",
+				Usage:  "record executed commands to fixture file",
+				Hidden: true,
 			},
+			&cli.StringFlag{
+				Name:   "replay",
+				Usage:  "replay commands from fixture file instead of running them",
+				Hidden: true,
+			},
+		},
+		Before: func(c *cli.Context) error {
+			run.command = c.Args().First()
+			run.context = c.String("context")
+			run.dryRun = c.Bool("dry-run")
+			switch c.String("log-format") {
+			case logFormatText:
+			case logFormatJSON:
+				mainLogger = logger.NewJSONLogger(&logger.Config{AppName: "platform"})
+			default:
+				return fmt.Errorf("unknown log format %v", c.String("log-format"))
+			}
+			if _, ok := readOnlyCommands[run.command]; ok {
+				return nil
+			}
+			if c.IsSet("image-builder") {
+				platformConfig.ImageBuilder = c.String("image-builder")
+			}
+			container, err2 := dependency.NewDependencyContainer(mainLogger, platformConfig, dependency.Options{
+				SilentMode:    os.Getenv("SILENT") != "",
+				DryRun:        c.Bool("dry-run"),
+				RecordFixture: c.String("record"),
+				ReplayFixture: c.String("replay"),
+				LogEvents:     c.String("log-format") == logFormatJSON,
+				RunID:         run.id,
+				RunLogDir:     runLogDir,
+			})
+			if err2 != nil {
+				return err2
+			}
+			run.container = container
+			c.Context = dependency.ContainerToContext(c.Context, container)
+			return nil
+		},
+		After: func(c *cli.Context) error {
+			container, err2 := dependency.ContainerFromContext(c.Context)
+			if err2 != nil {
+				return nil
+			}
+			container.Timings().Print(c.Args().First())
+			return container.Close()
 		},
 		Commands: cli.Commands{
 			&cli.Command{
-				Name: "checkout",
-				Action: func(c *cli.Context) error {
-					return checkout(c.Context, c.String("context"))
-				},
+				Name:   "checkout",
+				Action: checkoutContext,
 			},
 			&cli.Command{
 				Name: "build",
@@ -48,12 +119,13 @@ func main() {
 					&cli.BoolFlag{
 						Name: "push-images",
 					},
+					reportFlag,
 				},
-				Before: func(c *cli.Context) error {
-					return checkout(c.Context, c.String("context"))
-				},
+				Before: checkoutContext,
 				Action: func(c *cli.Context) error {
-					return build(c.Context, c.Bool("push-images"))
+					return withReports(c, func() error {
+						return build(c.Context, c.String("context"), c.Bool("push-images"))
+					})
 				},
 			},
 			&cli.Command{
@@ -70,15 +142,14 @@ func main() {
 						Required: true,
 					},
 				},
-				Before: func(c *cli.Context) error {
-					return checkout(c.Context, c.String("context"))
-				},
+				Before: checkoutContext,
 				Action: func(c *cli.Context) error {
 					return mergeContext(c.Context, c.String("from-context"))
 				},
 			},
 			&cli.Command{
-				Name: "push-context",
+				Name:   "push-context",
+				Before: requireContext,
 				Flags: []cli.Flag{
 					&cli.BoolFlag{
 						Name: "force",
@@ -89,25 +160,229 @@ func main() {
 				},
 			},
 			&cli.Command{
-				Name: "execute",
+				Name:      "run",
+				Usage:     "run target from platform-build.json in repositories",
+				ArgsUsage: "[command options] <target>",
+				Before:    requireContext,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name: "repositories",
+					},
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 1,
+					},
+					reportFlag,
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() != 1 {
+						return errors.New("target is required")
+					}
+					return withReports(c, func() error {
+						return runTarget(
+							c.Context,
+							c.String("context"),
+							c.Args().First(),
+							c.StringSlice("repositories"),
+							c.Int("parallel"),
+						)
+					})
+				},
+			},
+			&cli.Command{
+				Name:      "exec",
+				Usage:     "run shell command in repositories",
+				ArgsUsage: "[command options] -- <command> [args]",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name: "repositories",
+					},
+					&cli.IntFlag{
+						Name:  "parallel",
+						Value: 1,
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.NArg() == 0 {
+						return errors.New("command is required")
+					}
+					return execCommand(
+						c.Context,
+						c.StringSlice("repositories"),
+						c.Int("parallel"),
+						c.Args().First(),
+						c.Args().Tail(),
+					)
+				},
+			},
+			&cli.Command{
+				Name:  "logs",
+				Usage: "print full command output of run by repositories and phases",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "run",
+						Usage: "run id, latest run by default",
+					},
+					&cli.StringFlag{
+						Name: "repository",
+					},
+					&cli.IntFlag{
+						Name:  "tail",
+						Usage: "print only last lines of every log",
+					},
+					&cli.BoolFlag{
+						Name:    "follow",
+						Aliases: []string{"f"},
+						Usage:   "keep printing output of running commands until interrupted",
+					},
+					&cli.BoolFlag{
+						Name:  "list",
+						Usage: "list ids of logged runs",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					if c.Bool("list") {
+						return listRuns()
+					}
+					return printLogs(c.Context, c.String("run"), c.String("repository"), c.Int("tail"), c.Bool("follow"))
+				},
+			},
+			&cli.Command{
+				Name:  "history",
+				Usage: "list, show and diff recorded runs of platform commands",
+				Subcommands: cli.Commands{
+					&cli.Command{
+						Name: "list",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name: "command",
+							},
+							&cli.StringFlag{
+								Name:  "status",
+								Usage: "success, failed or interrupted",
+							},
+							&cli.StringFlag{
+								Name: "repository",
+							},
+							&cli.StringFlag{
+								Name:  "branch",
+								Usage: "branch of any repository, or of --repository when it is set",
+							},
+							&cli.BoolFlag{
+								Name:  "include-dry-run",
+								Usage: "list also dry-run runs, their digests are not real",
+							},
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "list only last runs",
+								Value: 20,
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return listHistory(history.Filter{
+								Command:       c.String("command"),
+								Context:       c.String("context"),
+								Status:        c.String("status"),
+								Repository:    c.String("repository"),
+								Branch:        c.String("branch"),
+								IncludeDryRun: c.Bool("include-dry-run"),
+							}, c.Int("limit"))
+						},
+					},
+					&cli.Command{
+						Name:      "show",
+						ArgsUsage: "<run-id|latest>",
+						Action: func(c *cli.Context) error {
+							id := history.Latest
+							if c.NArg() > 0 {
+								id = c.Args().First()
+							}
+							return showHistory(id)
+						},
+					},
+					&cli.Command{
+						Name:      "diff",
+						Usage:     "print repositories whose branch, commit or hash differ between runs",
+						ArgsUsage: "<run-id> <run-id|latest>",
+						Action: func(c *cli.Context) error {
+							if c.NArg() != 2 {
+								return errors.New("two run ids are required")
+							}
+							return diffHistory(c.Args().Get(0), c.Args().Get(1))
+						},
+					},
+				},
+			},
+			&cli.Command{
+				Name: "cache",
+				Subcommands: cli.Commands{
+					&cli.Command{
+						Name:  "prune",
+						Usage: "evict source cache and artifact entries exceeding max size",
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "all",
+								Usage: "remove all source cache and artifact entries",
+							},
+						},
+						Action: func(c *cli.Context) error {
+							return pruneCache(c.Context, c.Bool("all"))
+						},
+					},
+				},
+			},
+			&cli.Command{
+				Name:   "execute",
+				Before: requireContext,
 				Flags: []cli.Flag{
 					&cli.StringSliceFlag{
 						Name:     "pipelines",
 						Required: true,
 					},
+					reportFlag,
 				},
 				Action: func(c *cli.Context) error {
-					return executePipeline(c.Context, c.String("context"), c.StringSlice("pipelines"))
+					return withReports(c, func() error {
+						return executePipeline(c.Context, c.String("context"), c.StringSlice("pipelines"))
+					})
 				},
 			},
 		},
 	}
 	err = app.RunContext(ctx, os.Args)
+	err2 := run.record(ctx, err)
+	if err2 != nil {
+		mainLogger.Warning(err2, "failed to record run history")
+	}
 	if err != nil {
 		mainLogger.FatalError(err, "failed execute command "+strings.Join(os.Args, " "))
 	}
 }
 
+// readOnlyCommands only read files of platform, they do not need dependency container and are not recorded to history
+var readOnlyCommands = map[string]struct{}{
+	"":        {},
+	"help":    {},
+	"h":       {},
+	"history": {},
+	"logs":    {},
+}
+
+func requireContext(c *cli.Context) error {
+	if c.String("context") == "" {
+		return fmt.Errorf("required flag \"context\" is not set for command %v", c.Command.Name)
+	}
+	return nil
+}
+
+func checkoutContext(c *cli.Context) error {
+	err := requireContext(c)
+	if err != nil {
+		return err
+	}
+	return checkout(c.Context, c.String("context"))
+}
+
 func listenOSKillSignalsContext(ctx context.Context) context.Context {
 	var cancelFunc context.CancelFunc
 	ctx, cancelFunc = context.WithCancel(ctx)
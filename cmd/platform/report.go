@@ -0,0 +1,37 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/dependency"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/report"
+
+	"github.com/urfave/cli/v2"
+)
+
+var reportFlag = &cli.StringSliceFlag{
+	Name:  "report",
+	Usage: "write report of steps, format is junit:<path> or json:<path>",
+}
+
+// withReports runs action and writes reports of recorded steps even when action fails
+func withReports(c *cli.Context, action func() error) error {
+	targets := make([]report.Target, 0, len(c.StringSlice(reportFlag.Name)))
+	for _, spec := range c.StringSlice(reportFlag.Name) {
+		target, err := report.ParseTarget(spec)
+		if err != nil {
+			return err
+		}
+		targets = append(targets, target)
+	}
+	dependencyContainer, err := dependency.ContainerFromContext(c.Context)
+	if err != nil {
+		return err
+	}
+
+	err = action()
+	for _, target := range targets {
+		err = errors.Join(err, dependencyContainer.ReportRecorder().Write(target))
+	}
+	return err
+}
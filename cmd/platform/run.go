@@ -0,0 +1,15 @@
+package main
+
+import (
+	stdcontext "context"
+
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/dependency"
+)
+
+func runTarget(ctx stdcontext.Context, context string, target string, repositories []string, parallel int) error {
+	dependencyContainer, err := dependency.ContainerFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	return dependencyContainer.Platform().RunTarget(ctx, context, target, repositories, parallel)
+}
@@ -3,6 +3,18 @@ package build
 type Command struct {
 	Executable string
 	Args       []string
+	// WorkDir is relative to repository path
+	WorkDir string
+	Env     map[string]string
+	// Condition is template, command runs only when it renders to true
+	Condition string
+}
+
+// Secret is BuildKit secret taken from environment variable or file
+type Secret struct {
+	ID   string
+	Env  string
+	File string
 }
 
 type Image struct {
@@ -10,9 +22,37 @@ type Image struct {
 	Context    string
 	DockerFile string
 	SkipPush   bool
+	Labels     map[string]string
+	Platforms  []string
+	CacheFrom  []string
+	CacheTo    []string
+	// BuildArgs values are templates over repository and context data
+	BuildArgs map[string]string
+	Target    string
+	Secrets   []Secret
+}
+
+type InputMode string
+
+const (
+	InputModeCopy InputMode = "copy"
+	InputModeLink InputMode = "link"
+)
+
+// Input is output of dependency repository staged into Path before source build
+type Input struct {
+	Repository string
+	Output     string
+	Path       string
+	Mode       InputMode
 }
 
 type Config struct {
-	Sources Command
+	Sources []Command
 	Images  []Image
+	// Outputs are paths produced by source build and available for dependent repositories
+	Outputs []string
+	Inputs  []Input
+	// Targets are named command lists like test or lint
+	Targets map[string][]Command
 }
@@ -1,15 +1,30 @@
 package platform
 
+import "time"
+
 type ContextID = string
 
 type RepositoryID = string
 
 type Image = string
 
+// BranchTagPolicy describes when and how a repository gets a branch tag
+type BranchTagPolicy string
+
+const (
+	// BranchTagStrict tags with branch when the repository and all its dependencies are on context branches with the same name
+	BranchTagStrict BranchTagPolicy = "strict"
+	// BranchTagOwnBranch tags with own repository branch when the repository and all its dependencies are on context branches
+	BranchTagOwnBranch BranchTagPolicy = "own-branch"
+	// BranchTagContextName tags with context id when the repository and all its dependencies are on context branches
+	BranchTagContextName BranchTagPolicy = "context-name"
+)
+
 type Context struct {
 	ID            ContextID
 	BaseContextID *ContextID
 	Branches      map[RepositoryID]string
+	BranchTag     BranchTagPolicy
 }
 
 type Repository struct {
@@ -17,14 +32,61 @@ type Repository struct {
 	DependsOn []RepositoryID
 	GitSrc    string
 	Images    []Image
+	// Env is passed to git, build and pipeline commands of repository
+	Env map[string]string
 }
 
 type PipelineID = string
 
+// BuildCache contains default cache references templates for images,
+// nil means default cache reference and empty slice disables cache
+type BuildCache struct {
+	CacheFrom []string
+	CacheTo   []string
+}
+
+// BuildArgNames contains templates of build arg names for repository hashes and dependency image references
+type BuildArgNames struct {
+	Hash  string
+	Image string
+}
+
+// SourceCache configures local cache of source build outputs, MaxSize is in bytes
+type SourceCache struct {
+	Dir     string
+	MaxSize int64
+}
+
+// Call sites of network commands with configurable CommandPolicy
+const (
+	CommandClone     = "clone"
+	CommandFetch     = "fetch"
+	CommandPush      = "push"
+	CommandImagePush = "imagePush"
+)
+
+// CommandPolicy overrides timeout and retries of command call site, nil fields keep call site defaults
+type CommandPolicy struct {
+	Timeout *time.Duration
+	Retries *int
+	// Backoff is delay before first retry, doubled for every next retry
+	Backoff *time.Duration
+}
+
 type Platform struct {
-	RepoSrc      string
-	Registry     string
-	Contexts     map[ContextID]Context
-	Repositories []Repository
-	Pipelines    map[PipelineID]string
+	RepoSrc       string
+	Registry      string
+	Contexts      map[ContextID]Context
+	Repositories  []Repository
+	Pipelines     map[PipelineID]string
+	ImageTags     []string
+	ImageBuilder  string
+	BuildCache    BuildCache
+	BuildArgNames BuildArgNames
+	SourceCache   SourceCache
+	Commands      map[string]CommandPolicy
+	// GracePeriod is time given to interrupted commands to exit before they are killed
+	GracePeriod time.Duration
+	// RunLogRetention is number of run logs kept, older runs are removed when new run is logged
+	RunLogRetention int
 }
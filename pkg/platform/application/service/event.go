@@ -0,0 +1,73 @@
+package service
+
+import (
+	"time"
+
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+type EventType string
+
+const (
+	EventRepositoryResolved EventType = "repository.resolved"
+	EventCheckoutStarted    EventType = "checkout.started"
+	EventCheckoutFinished   EventType = "checkout.finished"
+	EventStepStarted        EventType = "step.started"
+	EventStepFinished       EventType = "step.finished"
+	EventImagePushed        EventType = "image.pushed"
+	EventPipelineFinished   EventType = "pipeline.finished"
+)
+
+// Phases of steps and commands, run logs and reports are grouped by them
+const (
+	PhaseClone    = "clone"
+	PhaseFetch    = "fetch"
+	PhaseCheckout = "checkout"
+	PhaseSources  = "sources"
+	PhaseImage    = "image"
+	PhasePush     = "push"
+	PhaseTarget   = "target"
+	PhasePipeline = "pipeline"
+	// PhaseGit is read-only git query, such as commit, branch or tags of repository
+	PhaseGit   = "git"
+	PhaseReset = "reset"
+	PhaseMerge = "merge"
+	PhaseExec  = "exec"
+)
+
+// Event is typed notification about progress of platform command
+type Event struct {
+	Type EventType
+	Time time.Time
+	// RepositoryID is empty for events not related to repository
+	RepositoryID platformconfig.RepositoryID
+	// Name is step name, branch of checkout, checked out branch of resolved repository or pipeline id
+	Name  string
+	Phase string
+	// Image and Digest are set for pushed images
+	Image  string
+	Digest string
+	// Commit and Hash are set for resolved repositories
+	Commit   string
+	Hash     string
+	Duration time.Duration
+	Skipped  bool
+	Err      error
+}
+
+type EventPublisher interface {
+	Publish(event Event)
+}
+
+// Step returns finished step described by event
+func (event Event) Step() Step {
+	return Step{
+		RepositoryID: event.RepositoryID,
+		Name:         event.Name,
+		Phase:        event.Phase,
+		Start:        event.Time.Add(-event.Duration),
+		Duration:     event.Duration,
+		Skipped:      event.Skipped,
+		Err:          event.Err,
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+// exitCodeError is result of command finished with non-zero exit code
+type exitCodeError int
+
+func (code exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", int(code))
+}
+
+func (service platform) Exec(
+	ctx context.Context,
+	repositoryIDs []platformconfig.RepositoryID,
+	parallel int,
+	executable string,
+	args []string,
+) error {
+	repositories, err := service.selectRepositories(repositoryIDs)
+	if err != nil {
+		return err
+	}
+	results := service.runParallel(repositories, parallel, func(repository platformconfig.Repository) error {
+		exitCode, err2 := service.commandExecutor.Execute(ctx, repository.ID, executable, args)
+		if err2 != nil {
+			return err2
+		}
+		if exitCode != 0 {
+			return exitCodeError(exitCode)
+		}
+		return nil
+	})
+
+	var failed int
+	service.logger.Info(fmt.Sprintf("%v summary:", executable))
+	for _, repository := range repositories {
+		result := results[repository.ID]
+		if result.Err != nil {
+			failed++
+			service.logger.Info(fmt.Sprintf("FAIL \"%v\" %v in %v", repository.ID, result.Err, result.Duration))
+			continue
+		}
+		service.logger.Info(fmt.Sprintf("PASS \"%v\" exit code 0 in %v", repository.ID, result.Duration))
+	}
+	if failed > 0 {
+		return fmt.Errorf("%v failed in %v of %v repositories", executable, failed, len(repositories))
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"time"
@@ -19,20 +20,39 @@ type RepositoryProvider interface {
 	RepositoryPath(id platformconfig.RepositoryID) string
 	Hash(ctx context.Context, repositoryID platformconfig.RepositoryID) (string, error)
 	BranchName(ctx context.Context, repositoryID platformconfig.RepositoryID) (string, error)
+	Tags(ctx context.Context, repositoryID platformconfig.RepositoryID) ([]string, error)
 	Reset(ctx context.Context, repositoryID platformconfig.RepositoryID) error
 	Merge(ctx context.Context, repositoryID platformconfig.RepositoryID, branch string) error
-	Push(ctx context.Context, repositoryID platformconfig.RepositoryID, dryRun bool) (string, error)
+	Push(ctx context.Context, repositoryID platformconfig.RepositoryID, dryRun bool) error
 }
 
 type RepositoryInfo struct {
 	platformconfig.Repository
+	Commit string
 	Hash   []byte
 	Branch *string
+	Tags   []string
 }
 
 type RepositoryBuilder interface {
-	Build(ctx context.Context, registry string, repositories map[platformconfig.RepositoryID]RepositoryInfo) error
-	Push(ctx context.Context, registry string, repositories map[platformconfig.RepositoryID]RepositoryInfo) error
+	// Build builds sources and images of repositories and pushes images when pushImages is set,
+	// images built for several platforms are pushed while building
+	Build(
+		ctx context.Context,
+		contextID platformconfig.ContextID,
+		registry string,
+		repositories map[platformconfig.RepositoryID]RepositoryInfo,
+		pushImages bool,
+	) error
+	// RunTarget runs named target of repository, returns ErrTargetNotFound when target is not defined
+	RunTarget(
+		ctx context.Context,
+		contextID platformconfig.ContextID,
+		registry string,
+		target string,
+		repository RepositoryInfo,
+		repositories map[platformconfig.RepositoryID]RepositoryInfo,
+	) error
 }
 
 type PipelineExecutor interface {
@@ -44,13 +64,55 @@ type PipelineExecutor interface {
 	) error
 }
 
+// Step is single recorded operation of platform command: sources build, image build, push, pipeline or target
+type Step struct {
+	// RepositoryID is empty for steps not related to repository
+	RepositoryID platformconfig.RepositoryID
+	Name         string
+	Phase        string
+	Start        time.Time
+	Duration     time.Duration
+	Skipped      bool
+	Err          error
+}
+
+type CommandExecutor interface {
+	// Execute runs command in repository directory and returns its exit code
+	Execute(
+		ctx context.Context,
+		repositoryID platformconfig.RepositoryID,
+		executable string,
+		args []string,
+	) (int, error)
+}
+
+type SourceCache interface {
+	// Prune evicts cache and artifact entries exceeding configured size or all entries
+	Prune(all bool) error
+}
+
 type Platform interface {
 	Checkout(ctx context.Context, context platformconfig.ContextID) error
-	Build(ctx context.Context, pushImages bool) error
+	Build(ctx context.Context, contextID platformconfig.ContextID, pushImages bool) error
 	ResetContext(ctx context.Context) error
 	MergeContext(ctx context.Context, fromContext platformconfig.ContextID) error
 	PushContext(ctx context.Context, context platformconfig.ContextID, force bool) error
 	ExecutePipelines(ctx context.Context, contextID platformconfig.ContextID, pipelines []string) error
+	PruneCache(all bool) error
+	RunTarget(
+		ctx context.Context,
+		contextID platformconfig.ContextID,
+		target string,
+		repositoryIDs []platformconfig.RepositoryID,
+		parallel int,
+	) error
+	Exec(
+		ctx context.Context,
+		repositoryIDs []platformconfig.RepositoryID,
+		parallel int,
+		executable string,
+		args []string,
+	) error
 }
 
 func NewPlatformService(
@@ -59,6 +121,9 @@ func NewPlatformService(
 	repositoryProvider RepositoryProvider,
 	repositoryBuilder RepositoryBuilder,
 	pipelineExecutor PipelineExecutor,
+	sourceCache SourceCache,
+	commandExecutor CommandExecutor,
+	events EventPublisher,
 ) Platform {
 	return &platform{
 		config:             config,
@@ -67,6 +132,9 @@ func NewPlatformService(
 		repositoryBuilder:  repositoryBuilder,
 		repositoryMap:      buildRepositoryMap(config),
 		pipelineExecutor:   pipelineExecutor,
+		sourceCache:        sourceCache,
+		commandExecutor:    commandExecutor,
+		events:             events,
 	}
 }
 
@@ -78,26 +146,13 @@ type platform struct {
 	repositoryProvider RepositoryProvider
 	repositoryBuilder  RepositoryBuilder
 	pipelineExecutor   PipelineExecutor
+	sourceCache        SourceCache
+	commandExecutor    CommandExecutor
+	events             EventPublisher
 }
 
 func (service platform) ExecutePipelines(ctx context.Context, contextID platformconfig.ContextID, pipelines []string) error {
-	repositoryMap := make(map[platformconfig.RepositoryID]RepositoryInfo)
-	err := service.iterateRepositories(func(repository platformconfig.Repository) error {
-		hash, err := service.buildRepositoryHash(ctx, repository)
-		if err != nil {
-			return err
-		}
-		branch, err := service.buildRepositoryBranch(ctx, repository)
-		if err != nil {
-			return err
-		}
-		repositoryMap[repository.ID] = RepositoryInfo{
-			repository,
-			hash,
-			branch,
-		}
-		return nil
-	})
+	repositoryMap, err := service.buildRepositoryInfoMap(ctx, contextID)
 	if err != nil {
 		return err
 	}
@@ -110,35 +165,12 @@ func (service platform) ExecutePipelines(ctx context.Context, contextID platform
 	return nil
 }
 
-func (service platform) Build(ctx context.Context, pushImages bool) error {
-	repositoryMap := make(map[platformconfig.RepositoryID]RepositoryInfo)
-	err := service.iterateRepositories(func(repository platformconfig.Repository) error {
-		hash, err := service.buildRepositoryHash(ctx, repository)
-		if err != nil {
-			return err
-		}
-		branch, err := service.buildRepositoryBranch(ctx, repository)
-		if err != nil {
-			return err
-		}
-		repositoryMap[repository.ID] = RepositoryInfo{
-			repository,
-			hash,
-			branch,
-		}
-		return nil
-	})
+func (service platform) Build(ctx context.Context, contextID platformconfig.ContextID, pushImages bool) error {
+	repositoryMap, err := service.buildRepositoryInfoMap(ctx, contextID)
 	if err != nil {
 		return err
 	}
-	err = service.repositoryBuilder.Build(ctx, service.config.Registry, repositoryMap)
-	if err != nil {
-		return err
-	}
-	if pushImages {
-		return service.repositoryBuilder.Push(ctx, service.config.Registry, repositoryMap)
-	}
-	return nil
+	return service.repositoryBuilder.Build(ctx, contextID, service.config.Registry, repositoryMap, pushImages)
 }
 
 func (service platform) Checkout(ctx context.Context, contextID platformconfig.ContextID) error {
@@ -152,10 +184,22 @@ func (service platform) Checkout(ctx context.Context, contextID platformconfig.C
 	if err != nil {
 		return err
 	}
-	return service.ResetContext(ctx)
+	err = service.resetContext(ctx)
+	if err != nil {
+		return err
+	}
+	return service.publishRepositoryStates(ctx)
 }
 
 func (service platform) ResetContext(ctx context.Context) error {
+	err := service.resetContext(ctx)
+	if err != nil {
+		return err
+	}
+	return service.publishRepositoryStates(ctx)
+}
+
+func (service platform) resetContext(ctx context.Context) error {
 	return service.iterateRepositories(func(repository platformconfig.Repository) error {
 		return service.repositoryProvider.Reset(ctx, repository.ID)
 	})
@@ -166,7 +210,7 @@ func (service platform) MergeContext(ctx context.Context, fromContext platformco
 	if !contextExist {
 		return fmt.Errorf("context with id %v not found", fromContext)
 	}
-	return service.iterateRepositories(func(repository platformconfig.Repository) error {
+	err := service.iterateRepositories(func(repository platformconfig.Repository) error {
 		currentBranch, err := service.repositoryProvider.BranchName(ctx, repository.ID)
 		if err != nil {
 			return err
@@ -185,6 +229,10 @@ func (service platform) MergeContext(ctx context.Context, fromContext platformco
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	return service.publishRepositoryStates(ctx)
 }
 
 func (service platform) PushContext(ctx context.Context, contextID platformconfig.ContextID, force bool) error {
@@ -196,7 +244,7 @@ func (service platform) PushContext(ctx context.Context, contextID platformconfi
 	if c.BaseContextID != nil {
 		bC = service.config.Contexts[*c.BaseContextID]
 	}
-	return service.iterateRepositories(func(repository platformconfig.Repository) error {
+	err := service.iterateRepositories(func(repository platformconfig.Repository) error {
 		baseBranch := bC.Branches[repository.ID]
 		branch, branchExist := c.Branches[repository.ID]
 		if !branchExist || branch == baseBranch {
@@ -204,28 +252,64 @@ func (service platform) PushContext(ctx context.Context, contextID platformconfi
 			return nil
 		}
 		service.logger.Info(fmt.Sprintf("push repository \"%v\" (dry-run = %v)", repository.ID, !force))
-		output, err := service.repositoryProvider.Push(ctx, repository.ID, !force)
-		service.logger.Info(output)
-		return err
+		return service.repositoryProvider.Push(ctx, repository.ID, !force)
 	})
+	if err != nil {
+		return err
+	}
+	return service.publishRepositoryStates(ctx)
+}
+
+func (service platform) PruneCache(all bool) error {
+	return service.sourceCache.Prune(all)
 }
 
-func (service platform) checkout(ctx context.Context, repository platformconfig.Repository, branch string) error {
+func (service platform) checkout(ctx context.Context, repository platformconfig.Repository, branch string) (err error) {
 	service.logger.Info(fmt.Sprintf("checkout \"%v\" to branch \"%v\"...", repository.ID, branch))
 	start := time.Now()
+	service.events.Publish(Event{Type: EventCheckoutStarted, Time: start, RepositoryID: repository.ID, Name: branch})
 	defer func() {
 		service.logger.Info(fmt.Sprintf("done in %v", time.Since(start).String()))
+		service.events.Publish(Event{
+			Type:         EventCheckoutFinished,
+			Time:         time.Now(),
+			RepositoryID: repository.ID,
+			Name:         branch,
+			Duration:     time.Since(start),
+			Err:          err,
+		})
 	}()
 
-	err := service.cloneIfNotExist(ctx, repository)
+	err = service.cloneIfNotExist(ctx, repository)
 	if err != nil {
 		return err
 	}
-	err = service.repositoryProvider.Fetch(ctx, repository)
+	err = service.step(repository.ID, PhaseFetch, "fetch", func() error {
+		return service.repositoryProvider.Fetch(ctx, repository)
+	})
 	if err != nil {
 		return err
 	}
-	return service.repositoryProvider.Checkout(ctx, repository, branch)
+	return service.step(repository.ID, PhaseCheckout, "checkout "+branch, func() error {
+		return service.repositoryProvider.Checkout(ctx, repository, branch)
+	})
+}
+
+// step runs f and publishes its start and finish as repository step events
+func (service platform) step(repositoryID platformconfig.RepositoryID, phase string, name string, f func() error) error {
+	start := time.Now()
+	service.events.Publish(Event{Type: EventStepStarted, Time: start, RepositoryID: repositoryID, Name: name, Phase: phase})
+	err := f()
+	service.events.Publish(Event{
+		Type:         EventStepFinished,
+		Time:         time.Now(),
+		RepositoryID: repositoryID,
+		Name:         name,
+		Phase:        phase,
+		Duration:     time.Since(start),
+		Err:          err,
+	})
+	return err
 }
 
 func (service platform) cloneIfNotExist(ctx context.Context, repository platformconfig.Repository) error {
@@ -234,7 +318,9 @@ func (service platform) cloneIfNotExist(ctx context.Context, repository platform
 		return err
 	}
 	if !exist {
-		return service.repositoryProvider.Clone(ctx, repository)
+		return service.step(repository.ID, PhaseClone, "clone", func() error {
+			return service.repositoryProvider.Clone(ctx, repository)
+		})
 	}
 	return nil
 }
@@ -249,6 +335,73 @@ func (service platform) iterateRepositories(f func(repository platformconfig.Rep
 	return nil
 }
 
+func (service platform) buildRepositoryInfoMap(
+	ctx context.Context,
+	contextID platformconfig.ContextID,
+) (map[platformconfig.RepositoryID]RepositoryInfo, error) {
+	c, ok := service.config.Contexts[contextID]
+	if !ok {
+		return nil, fmt.Errorf("context with id %v not found", contextID)
+	}
+	repositoryMap := make(map[platformconfig.RepositoryID]RepositoryInfo)
+	err := service.iterateRepositories(func(repository platformconfig.Repository) error {
+		commit, hash, err := service.resolveRepository(ctx, repository)
+		if err != nil {
+			return err
+		}
+		branch, err := service.buildRepositoryBranch(ctx, c, repository)
+		if err != nil {
+			return err
+		}
+		tags, err := service.repositoryProvider.Tags(ctx, repository.ID)
+		if err != nil {
+			return err
+		}
+		repositoryMap[repository.ID] = RepositoryInfo{
+			Repository: repository,
+			Commit:     commit,
+			Hash:       hash,
+			Branch:     branch,
+			Tags:       tags,
+		}
+		return nil
+	})
+	return repositoryMap, err
+}
+
+// publishRepositoryStates publishes checked out branch, commit and hash of repositories after command changed them
+func (service platform) publishRepositoryStates(ctx context.Context) error {
+	return service.iterateRepositories(func(repository platformconfig.Repository) error {
+		_, _, err := service.resolveRepository(ctx, repository)
+		return err
+	})
+}
+
+// resolveRepository returns commit and hash of repository and publishes them with checked out branch
+func (service platform) resolveRepository(ctx context.Context, repository platformconfig.Repository) (string, []byte, error) {
+	commit, err := service.repositoryProvider.Hash(ctx, repository.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	hash, err := service.buildRepositoryHash(ctx, repository)
+	if err != nil {
+		return "", nil, err
+	}
+	branch, err := service.repositoryProvider.BranchName(ctx, repository.ID)
+	if err != nil {
+		return "", nil, err
+	}
+	service.events.Publish(Event{
+		Type:         EventRepositoryResolved,
+		Time:         time.Now(),
+		RepositoryID: repository.ID,
+		Name:         branch,
+		Commit:       commit,
+		Hash:         hex.EncodeToString(hash),
+	})
+	return commit, hash, nil
+}
+
 func (service platform) buildRepositoryHash(ctx context.Context, repository platformconfig.Repository) ([]byte, error) {
 	hash := sha256.New()
 	commit, err := service.repositoryProvider.Hash(ctx, repository.ID)
@@ -266,22 +419,72 @@ func (service platform) buildRepositoryHash(ctx context.Context, repository plat
 	return hash.Sum(nil), nil
 }
 
-func (service platform) buildRepositoryBranch(ctx context.Context, repository platformconfig.Repository) (*string, error) {
-	b, err := service.repositoryProvider.BranchName(ctx, repository.ID)
+// buildRepositoryBranch returns branch tag for repository according to context branch tag policy,
+// nil means repository or one of its transitive dependencies is not on the context branch
+func (service platform) buildRepositoryBranch(
+	ctx context.Context,
+	c platformconfig.Context,
+	repository platformconfig.Repository,
+) (*string, error) {
+	repositoryBranch, err := service.repositoryProvider.BranchName(ctx, repository.ID)
 	if err != nil {
 		return nil, err
 	}
-	repositoryBranch := &b
-	for _, depends := range repository.DependsOn {
-		branch, err := service.buildRepositoryBranch(ctx, service.repositoryMap[depends])
+	if repositoryBranch != c.Branches[repository.ID] {
+		return nil, nil
+	}
+	sameBranch := true
+	for _, depends := range service.transitiveDependencies(repository) {
+		branch, err := service.repositoryProvider.BranchName(ctx, depends)
 		if err != nil {
 			return nil, err
 		}
-		if repositoryBranch != branch {
+		if branch != c.Branches[depends] {
+			return nil, nil
+		}
+		sameBranch = sameBranch && branch == repositoryBranch
+	}
+
+	switch c.BranchTag {
+	case platformconfig.BranchTagOwnBranch:
+		return &repositoryBranch, nil
+	case platformconfig.BranchTagContextName:
+		contextID := c.ID
+		return &contextID, nil
+	default:
+		if !sameBranch {
 			return nil, nil
 		}
+		return &repositoryBranch, nil
+	}
+}
+
+func (service platform) transitiveDependencies(repository platformconfig.Repository) []platformconfig.RepositoryID {
+	return TransitiveDependencies(repository, func(id platformconfig.RepositoryID) platformconfig.Repository {
+		return service.repositoryMap[id]
+	})
+}
+
+// TransitiveDependencies returns dependencies of repository in depth-first order, each dependency once
+func TransitiveDependencies(
+	repository platformconfig.Repository,
+	lookup func(id platformconfig.RepositoryID) platformconfig.Repository,
+) []platformconfig.RepositoryID {
+	visited := make(map[platformconfig.RepositoryID]struct{})
+	var result []platformconfig.RepositoryID
+	var visit func(repository platformconfig.Repository)
+	visit = func(repository platformconfig.Repository) {
+		for _, depends := range repository.DependsOn {
+			if _, ok := visited[depends]; ok {
+				continue
+			}
+			visited[depends] = struct{}{}
+			result = append(result, depends)
+			visit(lookup(depends))
+		}
 	}
-	return repositoryBranch, nil
+	visit(repository)
+	return result
 }
 
 func buildRepositoryMap(config platformconfig.Platform) map[platformconfig.RepositoryID]platformconfig.Repository {
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+type fakeRepositoryProvider struct {
+	RepositoryProvider
+	branches map[platformconfig.RepositoryID]string
+}
+
+func (provider fakeRepositoryProvider) BranchName(_ context.Context, repositoryID platformconfig.RepositoryID) (string, error) {
+	return provider.branches[repositoryID], nil
+}
+
+func TestBuildRepositoryBranch(t *testing.T) {
+	config := platformconfig.Platform{
+		Repositories: []platformconfig.Repository{
+			{ID: "frontend"},
+			{ID: "frontend-server", DependsOn: []platformconfig.RepositoryID{"frontend"}},
+			{ID: "gateway", DependsOn: []platformconfig.RepositoryID{"frontend-server"}},
+		},
+	}
+	featureContext := platformconfig.Context{
+		ID: "feature",
+		Branches: map[platformconfig.RepositoryID]string{
+			"frontend":        "feature/x",
+			"frontend-server": "feature/x",
+			"gateway":         "master",
+		},
+	}
+
+	testCases := []struct {
+		name       string
+		branchTag  platformconfig.BranchTagPolicy
+		branches   map[platformconfig.RepositoryID]string
+		repository platformconfig.RepositoryID
+		expected   *string
+	}{
+		{
+			name:       "strict without dependencies",
+			branchTag:  platformconfig.BranchTagStrict,
+			branches:   featureContext.Branches,
+			repository: "frontend",
+			expected:   toOptString("feature/x"),
+		},
+		{
+			name:       "strict with dependencies on same branch",
+			branchTag:  platformconfig.BranchTagStrict,
+			branches:   featureContext.Branches,
+			repository: "frontend-server",
+			expected:   toOptString("feature/x"),
+		},
+		{
+			name:       "strict with transitive dependencies on other branch",
+			branchTag:  platformconfig.BranchTagStrict,
+			branches:   featureContext.Branches,
+			repository: "gateway",
+			expected:   nil,
+		},
+		{
+			name:       "own branch with transitive dependencies on other branch",
+			branchTag:  platformconfig.BranchTagOwnBranch,
+			branches:   featureContext.Branches,
+			repository: "gateway",
+			expected:   toOptString("master"),
+		},
+		{
+			name:       "context name with transitive dependencies on other branch",
+			branchTag:  platformconfig.BranchTagContextName,
+			branches:   featureContext.Branches,
+			repository: "gateway",
+			expected:   toOptString("feature"),
+		},
+		{
+			name:      "transitive dependency out of context",
+			branchTag: platformconfig.BranchTagContextName,
+			branches: map[platformconfig.RepositoryID]string{
+				"frontend":        "master",
+				"frontend-server": "feature/x",
+				"gateway":         "master",
+			},
+			repository: "gateway",
+			expected:   nil,
+		},
+		{
+			name:      "repository out of context",
+			branchTag: platformconfig.BranchTagOwnBranch,
+			branches: map[platformconfig.RepositoryID]string{
+				"frontend":        "feature/x",
+				"frontend-server": "feature/y",
+				"gateway":         "master",
+			},
+			repository: "frontend-server",
+			expected:   nil,
+		},
+	}
+
+	for _, testCase := range testCases {
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			service := platform{
+				config:             config,
+				repositoryMap:      buildRepositoryMap(config),
+				repositoryProvider: fakeRepositoryProvider{branches: testCase.branches},
+			}
+			c := featureContext
+			c.BranchTag = testCase.branchTag
+
+			branch, err := service.buildRepositoryBranch(context.Background(), c, service.repositoryMap[testCase.repository])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !equalOptString(branch, testCase.expected) {
+				t.Errorf("expected branch %v, got %v", fromOptString(testCase.expected), fromOptString(branch))
+			}
+		})
+	}
+}
+
+func toOptString(v string) *string {
+	return &v
+}
+
+func fromOptString(v *string) string {
+	if v == nil {
+		return "<nil>"
+	}
+	return *v
+}
+
+func equalOptString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
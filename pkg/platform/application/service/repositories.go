@@ -0,0 +1,127 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+// ErrDependencyFailed is result of repository skipped because its selected dependency failed
+var ErrDependencyFailed = errors.New("dependency failed")
+
+type repositoryResult struct {
+	Err      error
+	Duration time.Duration
+}
+
+// selectRepositories returns repositories by ids or all repositories when ids are empty
+func (service platform) selectRepositories(repositoryIDs []platformconfig.RepositoryID) ([]platformconfig.Repository, error) {
+	if len(repositoryIDs) == 0 {
+		return service.config.Repositories, nil
+	}
+	result := make([]platformconfig.Repository, 0, len(repositoryIDs))
+	for _, repositoryID := range repositoryIDs {
+		repository, ok := service.repositoryMap[repositoryID]
+		if !ok {
+			return nil, fmt.Errorf("repository with id %v not found", repositoryID)
+		}
+		result = append(result, repository)
+	}
+	return result, nil
+}
+
+// runInDependencyOrder runs f for repositories with at most parallel concurrent calls,
+// repository starts after all its selected transitive dependencies are finished,
+// it is skipped with ErrDependencyFailed when one of them failed
+func (service platform) runInDependencyOrder(
+	repositories []platformconfig.Repository,
+	parallel int,
+	f func(repository platformconfig.Repository) error,
+) map[platformconfig.RepositoryID]repositoryResult {
+	return service.runRepositories(repositories, parallel, true, f)
+}
+
+// runParallel runs f for repositories with at most parallel concurrent calls in any order
+func (service platform) runParallel(
+	repositories []platformconfig.Repository,
+	parallel int,
+	f func(repository platformconfig.Repository) error,
+) map[platformconfig.RepositoryID]repositoryResult {
+	return service.runRepositories(repositories, parallel, false, f)
+}
+
+func (service platform) runRepositories(
+	repositories []platformconfig.Repository,
+	parallel int,
+	ordered bool,
+	f func(repository platformconfig.Repository) error,
+) map[platformconfig.RepositoryID]repositoryResult {
+	if parallel < 1 {
+		parallel = 1
+	}
+	done := make(map[platformconfig.RepositoryID]chan struct{}, len(repositories))
+	for _, repository := range repositories {
+		done[repository.ID] = make(chan struct{})
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[platformconfig.RepositoryID]repositoryResult, len(repositories))
+		slots   = make(chan struct{}, parallel)
+	)
+	for _, repository := range repositories {
+		wg.Add(1)
+		go func(repository platformconfig.Repository) {
+			defer wg.Done()
+			defer close(done[repository.ID])
+			if ordered {
+				err := service.waitDependencies(repository, done, func(depends platformconfig.RepositoryID) repositoryResult {
+					mu.Lock()
+					defer mu.Unlock()
+					return results[depends]
+				})
+				if err != nil {
+					mu.Lock()
+					defer mu.Unlock()
+					results[repository.ID] = repositoryResult{Err: err}
+					return
+				}
+			}
+			slots <- struct{}{}
+			start := time.Now()
+			err := f(repository)
+			<-slots
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[repository.ID] = repositoryResult{Err: err, Duration: time.Since(start)}
+		}(repository)
+	}
+	wg.Wait()
+	return results
+}
+
+// waitDependencies waits for selected transitive dependencies of repository and returns ErrDependencyFailed
+// when one of them failed, dependency without target is not failed
+func (service platform) waitDependencies(
+	repository platformconfig.Repository,
+	done map[platformconfig.RepositoryID]chan struct{},
+	result func(depends platformconfig.RepositoryID) repositoryResult,
+) error {
+	for _, depends := range service.transitiveDependencies(repository) {
+		dependencyDone, ok := done[depends]
+		if !ok {
+			continue
+		}
+		<-dependencyDone
+		err := result(depends).Err
+		if err != nil && !errors.Is(err, ErrTargetNotFound) {
+			return fmt.Errorf("%w: %v", ErrDependencyFailed, depends)
+		}
+	}
+	return nil
+}
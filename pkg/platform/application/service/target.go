@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+var ErrTargetNotFound = errors.New("target not found")
+
+func (service platform) RunTarget(
+	ctx context.Context,
+	contextID platformconfig.ContextID,
+	target string,
+	repositoryIDs []platformconfig.RepositoryID,
+	parallel int,
+) error {
+	repositories, err := service.selectRepositories(repositoryIDs)
+	if err != nil {
+		return err
+	}
+	repositoryMap, err := service.buildRepositoryInfoMap(ctx, contextID)
+	if err != nil {
+		return err
+	}
+
+	results := service.runInDependencyOrder(repositories, parallel, func(repository platformconfig.Repository) error {
+		return service.repositoryBuilder.RunTarget(
+			ctx,
+			contextID,
+			service.config.Registry,
+			target,
+			repositoryMap[repository.ID],
+			repositoryMap,
+		)
+	})
+
+	var failed, passed int
+	service.logger.Info(fmt.Sprintf("target %v summary:", target))
+	for _, repository := range repositories {
+		result := results[repository.ID]
+		switch {
+		case errors.Is(result.Err, ErrTargetNotFound):
+			service.logger.Info(fmt.Sprintf("SKIP \"%v\"", repository.ID))
+		case errors.Is(result.Err, ErrDependencyFailed):
+			service.logger.Info(fmt.Sprintf("SKIP \"%v\", %v", repository.ID, result.Err))
+			service.events.Publish(Event{
+				Type:         EventStepFinished,
+				Time:         time.Now(),
+				RepositoryID: repository.ID,
+				Name:         "target " + target,
+				Phase:        PhaseTarget,
+				Skipped:      true,
+				Err:          result.Err,
+			})
+		case result.Err != nil:
+			failed++
+			service.logger.Error(result.Err, fmt.Sprintf("FAIL \"%v\" in %v", repository.ID, result.Duration))
+		default:
+			passed++
+			service.logger.Info(fmt.Sprintf("PASS \"%v\" in %v", repository.ID, result.Duration))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("target %v failed in %v of %v repositories", target, failed, failed+passed)
+	}
+	return nil
+}
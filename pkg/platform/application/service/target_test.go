@@ -0,0 +1,95 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+func TestRunInDependencyOrder(t *testing.T) {
+	config := platformconfig.Platform{
+		Repositories: []platformconfig.Repository{
+			{ID: "gateway", DependsOn: []platformconfig.RepositoryID{"frontend-server"}},
+			{ID: "frontend-server", DependsOn: []platformconfig.RepositoryID{"frontend"}},
+			{ID: "frontend"},
+			{ID: "backend"},
+		},
+	}
+	service := platform{
+		config:        config,
+		repositoryMap: buildRepositoryMap(config),
+	}
+
+	for _, parallel := range []int{1, 4} {
+		var (
+			mu    sync.Mutex
+			order []platformconfig.RepositoryID
+		)
+		results := service.runInDependencyOrder(config.Repositories, parallel, func(repository platformconfig.Repository) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, repository.ID)
+			return nil
+		})
+
+		if len(results) != len(config.Repositories) {
+			t.Fatalf("expected %v results, got %v", len(config.Repositories), len(results))
+		}
+		position := make(map[platformconfig.RepositoryID]int)
+		for i, repositoryID := range order {
+			position[repositoryID] = i
+		}
+		if position["frontend"] > position["frontend-server"] || position["frontend-server"] > position["gateway"] {
+			t.Errorf("unexpected order %v with parallel %v", order, parallel)
+		}
+	}
+}
+
+func TestRunInDependencyOrderSkipsDependentsOfFailed(t *testing.T) {
+	config := platformconfig.Platform{
+		Repositories: []platformconfig.Repository{
+			{ID: "gateway", DependsOn: []platformconfig.RepositoryID{"frontend-server"}},
+			{ID: "frontend-server", DependsOn: []platformconfig.RepositoryID{"frontend"}},
+			{ID: "frontend", DependsOn: []platformconfig.RepositoryID{"lib"}},
+			{ID: "lib"},
+			{ID: "backend", DependsOn: []platformconfig.RepositoryID{"lib"}},
+		},
+	}
+	service := platform{
+		config:        config,
+		repositoryMap: buildRepositoryMap(config),
+	}
+	failure := errors.New("failure")
+
+	var mu sync.Mutex
+	var executed []platformconfig.RepositoryID
+	results := service.runInDependencyOrder(config.Repositories, 2, func(repository platformconfig.Repository) error {
+		mu.Lock()
+		defer mu.Unlock()
+		executed = append(executed, repository.ID)
+		switch repository.ID {
+		case "lib":
+			return ErrTargetNotFound
+		case "frontend":
+			return failure
+		}
+		return nil
+	})
+
+	for repositoryID, expected := range map[platformconfig.RepositoryID]error{
+		"lib":             ErrTargetNotFound,
+		"frontend":        failure,
+		"frontend-server": ErrDependencyFailed,
+		"gateway":         ErrDependencyFailed,
+		"backend":         nil,
+	} {
+		if err := results[repositoryID].Err; !errors.Is(err, expected) {
+			t.Errorf("expected %v result %v, got %v", repositoryID, expected, err)
+		}
+	}
+	if len(executed) != 3 {
+		t.Errorf("expected dependents of failed repository not to run, executed %v", executed)
+	}
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+// TimingTree collects durations of finished steps by repository and phase
+type TimingTree struct {
+	config platformconfig.Platform
+	logger applogger.Logger
+
+	mu    sync.Mutex
+	steps []Step
+}
+
+type repositoryTiming struct {
+	RepositoryID platformconfig.RepositoryID
+	Total        time.Duration
+	Phases       map[string]time.Duration
+}
+
+func NewTimingTree(config platformconfig.Platform, logger applogger.Logger) *TimingTree {
+	return &TimingTree{
+		config: config,
+		logger: logger,
+	}
+}
+
+// Handle collects finished steps and pipelines, tree subscribes it to event stream
+func (tree *TimingTree) Handle(event Event) {
+	if event.Type != EventStepFinished && event.Type != EventPipelineFinished {
+		return
+	}
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+	tree.steps = append(tree.steps, event.Step())
+}
+
+// Print logs timing summary of command sorted by duration and critical path through repository dependencies
+func (tree *TimingTree) Print(command string) {
+	tree.mu.Lock()
+	steps := make([]Step, len(tree.steps))
+	copy(steps, tree.steps)
+	tree.mu.Unlock()
+	if len(steps) == 0 {
+		return
+	}
+
+	timings := repositoryTimings(steps)
+	// steps of parallel commands overlap, so total is wall time from first start to last finish
+	start, finish := steps[0].Start, steps[0].Start.Add(steps[0].Duration)
+	for _, step := range steps {
+		if step.Start.Before(start) {
+			start = step.Start
+		}
+		if end := step.Start.Add(step.Duration); end.After(finish) {
+			finish = end
+		}
+	}
+	total := finish.Sub(start)
+	sorted := make([]repositoryTiming, 0, len(timings))
+	for _, timing := range timings {
+		sorted = append(sorted, timing)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Total > sorted[j].Total
+	})
+
+	buffer := &bytes.Buffer{}
+	writer := tabwriter.NewWriter(buffer, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(writer, "%v\t\t%v\n", command, formatDuration(total))
+	for _, timing := range sorted {
+		_, _ = fmt.Fprintf(writer, "  %v\t\t%v\n", timing.displayName(), formatDuration(timing.Total))
+		phases := make([]string, 0, len(timing.Phases))
+		for phase := range timing.Phases {
+			phases = append(phases, phase)
+		}
+		sort.Slice(phases, func(i, j int) bool {
+			return timing.Phases[phases[i]] > timing.Phases[phases[j]]
+		})
+		for _, phase := range phases {
+			_, _ = fmt.Fprintf(writer, "  \t%v\t%v\n", phase, formatDuration(timing.Phases[phase]))
+		}
+	}
+	_ = writer.Flush()
+
+	tree.logger.Info("timing summary:")
+	for _, line := range strings.Split(strings.TrimRight(buffer.String(), "\n"), "\n") {
+		tree.logger.Info(line)
+	}
+	path, duration := tree.criticalPath(timings)
+	if len(path) > 1 {
+		tree.logger.Info(fmt.Sprintf("critical path %v: %v", formatDuration(duration), strings.Join(path, " -> ")))
+	}
+}
+
+// criticalPath returns the longest chain of dependent repositories by their total durations
+func (tree *TimingTree) criticalPath(
+	timings map[platformconfig.RepositoryID]repositoryTiming,
+) ([]platformconfig.RepositoryID, time.Duration) {
+	repositoryMap := buildRepositoryMap(tree.config)
+	finish := make(map[platformconfig.RepositoryID]time.Duration)
+	previous := make(map[platformconfig.RepositoryID]platformconfig.RepositoryID)
+	var visit func(repositoryID platformconfig.RepositoryID) time.Duration
+	visit = func(repositoryID platformconfig.RepositoryID) time.Duration {
+		if duration, ok := finish[repositoryID]; ok {
+			return duration
+		}
+		var longest time.Duration
+		for _, depends := range repositoryMap[repositoryID].DependsOn {
+			if duration := visit(depends); duration > longest {
+				longest = duration
+				previous[repositoryID] = depends
+			}
+		}
+		finish[repositoryID] = longest + timings[repositoryID].Total
+		return finish[repositoryID]
+	}
+
+	var (
+		last     platformconfig.RepositoryID
+		duration time.Duration
+	)
+	for _, repository := range tree.config.Repositories {
+		if d := visit(repository.ID); d > duration {
+			last, duration = repository.ID, d
+		}
+	}
+	var path []platformconfig.RepositoryID
+	for repositoryID := last; repositoryID != ""; repositoryID = previous[repositoryID] {
+		if _, ok := timings[repositoryID]; ok {
+			path = append([]platformconfig.RepositoryID{repositoryID}, path...)
+		}
+	}
+	return path, duration
+}
+
+func repositoryTimings(steps []Step) map[platformconfig.RepositoryID]repositoryTiming {
+	result := make(map[platformconfig.RepositoryID]repositoryTiming)
+	for _, step := range steps {
+		timing, ok := result[step.RepositoryID]
+		if !ok {
+			timing = repositoryTiming{RepositoryID: step.RepositoryID, Phases: make(map[string]time.Duration)}
+		}
+		timing.Total += step.Duration
+		timing.Phases[step.Phase] += step.Duration
+		result[step.RepositoryID] = timing
+	}
+	return result
+}
+
+func (timing repositoryTiming) displayName() string {
+	if timing.RepositoryID == "" {
+		return "platform"
+	}
+	return timing.RepositoryID
+}
+
+func formatDuration(duration time.Duration) string {
+	return duration.Round(time.Millisecond).String()
+}
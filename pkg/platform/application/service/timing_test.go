@@ -0,0 +1,34 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	platformconfig "github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+func TestTimingTreeCriticalPath(t *testing.T) {
+	config := platformconfig.Platform{
+		Repositories: []platformconfig.Repository{
+			{ID: "gateway", DependsOn: []platformconfig.RepositoryID{"frontend-server", "backend"}},
+			{ID: "frontend-server", DependsOn: []platformconfig.RepositoryID{"frontend"}},
+			{ID: "frontend"},
+			{ID: "backend"},
+		},
+	}
+	tree := NewTimingTree(config, nil)
+	for repositoryID, duration := range map[platformconfig.RepositoryID]time.Duration{
+		"gateway":         time.Second,
+		"frontend-server": 2 * time.Second,
+		"frontend":        3 * time.Second,
+		"backend":         5 * time.Second,
+	} {
+		tree.Handle(Event{Type: EventStepFinished, RepositoryID: repositoryID, Phase: PhaseSources, Duration: duration})
+	}
+
+	path, duration := tree.criticalPath(repositoryTimings(tree.steps))
+	if strings.Join(path, " -> ") != "frontend -> frontend-server -> gateway" || duration != 6*time.Second {
+		t.Errorf("unexpected critical path %v in %v", path, duration)
+	}
+}
@@ -0,0 +1,156 @@
+package builder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/build"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const artifactsDir = ".platform/artifacts"
+
+func artifactPath(repository service.RepositoryInfo, output string) string {
+	return path.Join(artifactsDir, repository.ID, hex.EncodeToString(repository.Hash), output)
+}
+
+// touchArtifacts updates usage time of repository artifacts, cache prune evicts least recently used artifacts
+func touchArtifacts(repository service.RepositoryInfo) error {
+	now := time.Now()
+	entryPath := artifactPath(repository, "")
+	return errors.Wrapf(os.Chtimes(entryPath, now, now), "failed to touch artifacts %v", entryPath)
+}
+
+// collectOutputs copies outputs of source build to artifacts directory
+func (builder repositoryBuilder) collectOutputs(repository service.RepositoryInfo, buildConfig build.Config) error {
+	repositoryPath := builder.repositoryProvider.RepositoryPath(repository.ID)
+	for _, output := range buildConfig.Outputs {
+		destination := artifactPath(repository, output)
+		err := os.RemoveAll(destination)
+		if err != nil {
+			return errors.Wrapf(err, "failed to clean artifact %v", destination)
+		}
+		err = copyPath(path.Join(repositoryPath, output), destination)
+		if err != nil {
+			return errors.Wrapf(err, "failed to collect output %v of repository %v", output, repository.ID)
+		}
+	}
+	if len(buildConfig.Outputs) == 0 {
+		return nil
+	}
+	return touchArtifacts(repository)
+}
+
+// stageInputs copies or links outputs of dependencies into repository before source build
+func (builder repositoryBuilder) stageInputs(
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+	buildConfig build.Config,
+) error {
+	if len(buildConfig.Inputs) == 0 {
+		return nil
+	}
+	dependencies := make(map[platform.RepositoryID]struct{})
+	for _, depends := range transitiveDependencies(repository, repositories) {
+		dependencies[depends] = struct{}{}
+	}
+
+	repositoryPath := builder.repositoryProvider.RepositoryPath(repository.ID)
+	for _, input := range buildConfig.Inputs {
+		if _, ok := dependencies[input.Repository]; !ok {
+			return fmt.Errorf("input %v of repository %v is not from its dependency %v", input.Output, repository.ID, input.Repository)
+		}
+		source := artifactPath(repositories[input.Repository], input.Output)
+		if !exist(source) {
+			return fmt.Errorf("output %v of repository %v not found, is it declared in outputs?", input.Output, input.Repository)
+		}
+		err := touchArtifacts(repositories[input.Repository])
+		if err != nil {
+			return err
+		}
+		destination := path.Join(repositoryPath, input.Path)
+		err = os.RemoveAll(destination)
+		if err != nil {
+			return errors.Wrapf(err, "failed to clean input path %v", destination)
+		}
+		err = os.MkdirAll(path.Dir(destination), 0o755)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create directory for input %v", destination)
+		}
+		switch input.Mode {
+		case build.InputModeLink:
+			absoluteSource, err2 := filepath.Abs(source)
+			if err2 != nil {
+				return err2
+			}
+			err = os.Symlink(absoluteSource, destination)
+		default:
+			err = copyPath(source, destination)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to stage input %v from repository %v", input.Output, input.Repository)
+		}
+		builder.logger.Info(fmt.Sprintf("staged %v from \"%v\" to %v", input.Output, input.Repository, destination))
+	}
+	return nil
+}
+
+func copyPath(source, destination string) error {
+	return filepath.WalkDir(source, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relative, err := filepath.Rel(source, p)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destination, relative)
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case entry.IsDir():
+			return os.MkdirAll(target, info.Mode().Perm()|0o700)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err2 := os.Readlink(p)
+			if err2 != nil {
+				return err2
+			}
+			return os.Symlink(link, target)
+		default:
+			return copyFile(p, target, info.Mode().Perm())
+		}
+	})
+}
+
+func copyFile(source, destination string, perm fs.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(destination), 0o755)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,120 @@
+package builder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const registryBuildArg = "REGISTRY"
+
+var (
+	defaultBuildArgNames = platform.BuildArgNames{
+		Hash:  "{{.RepositoryID}}",
+		Image: "{{.RepositoryID}}_IMAGE_{{.Image}}",
+	}
+	invalidBuildArgSymbols = regexp.MustCompile(`[^A-Z0-9_]`)
+)
+
+type buildArgNameVariables struct {
+	RepositoryID string
+	Image        string
+	ImageBase    string
+}
+
+func newBuildArgNaming(names platform.BuildArgNames) buildArgNaming {
+	if names.Hash == "" {
+		names.Hash = defaultBuildArgNames.Hash
+	}
+	if names.Image == "" {
+		names.Image = defaultBuildArgNames.Image
+	}
+	return buildArgNaming{names: names}
+}
+
+// buildArgNaming names build args with hashes and image references of repository and its transitive dependencies
+type buildArgNaming struct {
+	names platform.BuildArgNames
+}
+
+func (naming buildArgNaming) BuildArgs(
+	registry string,
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+) (map[string]string, error) {
+	args := buildArgs{
+		values:  map[string]string{registryBuildArg: registry},
+		sources: map[string]string{registryBuildArg: "registry"},
+	}
+	err := naming.addRepository(args, repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, depends := range transitiveDependencies(repository, repositories) {
+		dependency := repositories[depends]
+		err = naming.addRepository(args, dependency)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range dependency.Images {
+			name, err2 := naming.name(naming.names.Image, dependency.ID, image)
+			if err2 != nil {
+				return nil, err2
+			}
+			err2 = args.add(name, buildTag(registry, image, hex.EncodeToString(dependency.Hash)), fmt.Sprintf("image %v of repository %v", image, dependency.ID))
+			if err2 != nil {
+				return nil, err2
+			}
+		}
+	}
+	return args.values, nil
+}
+
+func (naming buildArgNaming) addRepository(args buildArgs, repository service.RepositoryInfo) error {
+	name, err := naming.name(naming.names.Hash, repository.ID, "")
+	if err != nil {
+		return err
+	}
+	return args.add(name, hex.EncodeToString(repository.Hash), fmt.Sprintf("hash of repository %v", repository.ID))
+}
+
+func (naming buildArgNaming) name(expression string, repositoryID platform.RepositoryID, image string) (string, error) {
+	name, err := executeTemplate("build arg name", expression, buildArgNameVariables{
+		RepositoryID: repositoryID,
+		Image:        image,
+		ImageBase:    path.Base(image),
+	})
+	if err != nil {
+		return "", err
+	}
+	return invalidBuildArgSymbols.ReplaceAllString(strings.ToUpper(name), "_"), nil
+}
+
+type buildArgs struct {
+	values map[string]string
+	// sources describe origin of each build arg to report collisions
+	sources map[string]string
+}
+
+func (args buildArgs) add(name, value, source string) error {
+	if existing, ok := args.sources[name]; ok {
+		return fmt.Errorf("build arg %v for %v collides with %v", name, source, existing)
+	}
+	args.values[name] = value
+	args.sources[name] = source
+	return nil
+}
+
+func transitiveDependencies(
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+) []platform.RepositoryID {
+	return service.TransitiveDependencies(repository.Repository, func(id platform.RepositoryID) platform.Repository {
+		return repositories[id].Repository
+	})
+}
@@ -0,0 +1,131 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/build"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/provider"
+)
+
+func testRepository(id string, hash byte, images []string, dependsOn ...string) service.RepositoryInfo {
+	return service.RepositoryInfo{
+		Repository: platform.Repository{ID: id, DependsOn: dependsOn, Images: images},
+		Commit:     strings.Repeat("c", 40),
+		Hash:       []byte{hash},
+	}
+}
+
+func TestBuildArgs(t *testing.T) {
+	for _, testCase := range []struct {
+		name         string
+		names        platform.BuildArgNames
+		repositories []service.RepositoryInfo
+		image        build.Image
+		expected     map[string]string
+		err          string
+	}{
+		{
+			name: "transitive hashes and images",
+			repositories: []service.RepositoryInfo{
+				testRepository("app", 0x01, []string{"app"}, "lib"),
+				testRepository("lib", 0x02, nil, "base"),
+				testRepository("base", 0x03, []string{"base"}),
+			},
+			expected: map[string]string{
+				"REGISTRY":        "registry",
+				"APP":             "01",
+				"LIB":             "02",
+				"BASE":            "03",
+				"BASE_IMAGE_BASE": "registry/base:03",
+			},
+		},
+		{
+			name: "image args over common args",
+			repositories: []service.RepositoryInfo{
+				testRepository("app", 0x01, []string{"app"}),
+			},
+			image: build.Image{Name: "app", BuildArgs: map[string]string{
+				"TARGET": "{{.RepositoryID}}-{{.Image}}",
+				"APP":    "override",
+			}},
+			expected: map[string]string{
+				"REGISTRY": "registry",
+				"APP":      "override",
+				"TARGET":   "app-app",
+			},
+		},
+		{
+			name:  "custom names",
+			names: platform.BuildArgNames{Hash: "{{.RepositoryID}}_HASH", Image: "{{.ImageBase}}_IMAGE"},
+			repositories: []service.RepositoryInfo{
+				testRepository("app", 0x01, nil, "frontend"),
+				testRepository("frontend", 0x02, []string{"web/frontend"}),
+			},
+			expected: map[string]string{
+				"REGISTRY":       "registry",
+				"APP_HASH":       "01",
+				"FRONTEND_HASH":  "02",
+				"FRONTEND_IMAGE": "registry/web/frontend:02",
+			},
+		},
+		{
+			name: "collision of sanitized names",
+			repositories: []service.RepositoryInfo{
+				testRepository("app", 0x01, nil, "frontend-server", "frontend_server"),
+				testRepository("frontend-server", 0x02, nil),
+				testRepository("frontend_server", 0x03, nil),
+			},
+			err: "build arg FRONTEND_SERVER for hash of repository frontend_server collides with hash of repository frontend-server",
+		},
+		{
+			name: "collision with registry",
+			repositories: []service.RepositoryInfo{
+				testRepository("app", 0x01, nil, "registry"),
+				testRepository("registry", 0x02, nil),
+			},
+			err: "build arg REGISTRY for hash of repository registry collides with registry",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			repositories := make(map[platform.RepositoryID]service.RepositoryInfo)
+			for _, repository := range testCase.repositories {
+				repositories[repository.ID] = repository
+			}
+			repository := testCase.repositories[0]
+			args, err := newBuildArgNaming(testCase.names).BuildArgs("registry", repository, repositories)
+			if err == nil && testCase.image.Name != "" {
+				args, err = imageBuildArgs("context", "registry", testCase.image, repository, args)
+			}
+			if testCase.err != "" {
+				if err == nil || err.Error() != testCase.err {
+					t.Fatalf("expected error %v, got %v", testCase.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(args, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, args)
+			}
+		})
+	}
+}
+
+func TestCommandEnvCollision(t *testing.T) {
+	repositories := map[platform.RepositoryID]service.RepositoryInfo{
+		"app": testRepository("app", 0x01, nil, "a-b", "a_b"),
+		"a-b": testRepository("a-b", 0x02, nil),
+		"a_b": testRepository("a_b", 0x03, nil),
+	}
+	builder := repositoryBuilder{repositoryProvider: provider.NewRepositoryProvider("src", nil, nil, nil)}
+	_, err := builder.commandEnv("registry", repositories["app"], repositories)
+	expected := "env PLATFORM_DEPENDENCY_A_B for dependency a_b collides with dependency a-b"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected error %v, got %v", expected, err)
+	}
+}
@@ -0,0 +1,93 @@
+package builder
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/build"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const defaultCacheReference = "{{.Registry}}/{{.Image}}:buildcache"
+
+type cacheVariables struct {
+	ContextID    string
+	RepositoryID string
+	Registry     string
+	Image        string
+	Hash         string
+	// Branch is sanitized to be used as image tag
+	Branch string
+}
+
+func newCachePolicy(defaults platform.BuildCache) cachePolicy {
+	return cachePolicy{defaults: defaults}
+}
+
+// cachePolicy resolves cache references of image: image settings override platform defaults,
+// nil settings fall back to registry cache reference and empty settings disable cache
+type cachePolicy struct {
+	defaults platform.BuildCache
+}
+
+func (policy cachePolicy) References(
+	contextID platform.ContextID,
+	registry string,
+	image build.Image,
+	repository service.RepositoryInfo,
+) (cacheFrom []string, cacheTo []string, err error) {
+	variables := cacheVariables{
+		ContextID:    contextID,
+		RepositoryID: repository.ID,
+		Registry:     registry,
+		Image:        image.Name,
+		Hash:         hex.EncodeToString(repository.Hash),
+	}
+	if repository.Branch != nil {
+		variables.Branch = sanitizeTag(*repository.Branch)
+	}
+	cacheFrom, err = renderCacheReferences(resolveCacheReferences(image.CacheFrom, policy.defaults.CacheFrom), variables)
+	if err != nil {
+		return nil, nil, err
+	}
+	cacheTo, err = renderCacheReferences(resolveCacheReferences(image.CacheTo, policy.defaults.CacheTo), variables)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cacheFrom, cacheTo, nil
+}
+
+func resolveCacheReferences(imageReferences, defaultReferences []string) []string {
+	if imageReferences != nil {
+		return imageReferences
+	}
+	if defaultReferences != nil {
+		return defaultReferences
+	}
+	return []string{defaultCacheReference}
+}
+
+func renderCacheReferences(expressions []string, variables cacheVariables) ([]string, error) {
+	result := make([]string, 0, len(expressions))
+	for _, expression := range expressions {
+		reference, err := executeTemplate("cache reference", expression, variables)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(reference) != "" {
+			result = append(result, strings.TrimSpace(reference))
+		}
+	}
+	return result, nil
+}
+
+// referenceRepository strips tag from image reference
+func referenceRepository(reference string) string {
+	slash := strings.LastIndex(reference, "/")
+	colon := strings.LastIndex(reference, ":")
+	if colon > slash {
+		return reference[:colon]
+	}
+	return reference
+}
@@ -0,0 +1,87 @@
+package builder
+
+import (
+	stdcontext "context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+// containersImageBuilder builds images with tools based on containers/image: podman and buildah
+type containersImageBuilder struct {
+	executable string
+	runner     command.Runner
+	pushPolicy command.Policy
+}
+
+func (builder containersImageBuilder) Build(ctx stdcontext.Context, request ImageBuildRequest) (string, error) {
+	if len(request.Platforms) > 0 {
+		return "", fmt.Errorf("multi-platform builds are not supported by %v backend, use %v", builder.executable, BackendDockerBuildx)
+	}
+	args := []string{"build"}
+	args = append(args, commonBuildArgs(request)...)
+	args = append(args, cacheArgs(request)...)
+	args = append(args, request.Context)
+	_, err := builder.runner.Execute(ctx, command.Command{
+		WorkDir:    request.WorkDir,
+		Executable: builder.executable,
+		Args:       args,
+		Env:        request.Env,
+		Verbose:    true,
+		Repository: request.Repository,
+		Phase:      service.PhaseImage,
+	})
+	return "", err
+}
+
+// cacheArgs translates cache references to repositories, because podman and buildah do not accept tagged cache references
+func cacheArgs(request ImageBuildRequest) []string {
+	if len(request.CacheFrom) == 0 && len(request.CacheTo) == 0 {
+		return nil
+	}
+	result := []string{"--layers"}
+	for _, reference := range request.CacheFrom {
+		result = append(result, "--cache-from="+referenceRepository(reference))
+	}
+	for _, reference := range request.CacheTo {
+		result = append(result, "--cache-to="+referenceRepository(reference))
+	}
+	return result
+}
+
+func (builder containersImageBuilder) Push(
+	ctx stdcontext.Context,
+	repositoryID platform.RepositoryID,
+	workDir string,
+	reference string,
+) (string, error) {
+	digestFile, err := os.CreateTemp("", "digest")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temporary digest file")
+	}
+	_ = digestFile.Close()
+	defer os.Remove(digestFile.Name())
+
+	_, err = builder.runner.Execute(ctx, builder.pushPolicy.Apply(command.Command{
+		WorkDir:    workDir,
+		Executable: builder.executable,
+		Args:       []string{"push", "--digestfile=" + digestFile.Name(), reference},
+		Verbose:    true,
+		Repository: repositoryID,
+		Phase:      service.PhasePush,
+	}))
+	if err != nil {
+		return "", err
+	}
+	digest, err := os.ReadFile(digestFile.Name())
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read digest of pushed image %v", reference)
+	}
+	return strings.TrimSpace(string(digest)), nil
+}
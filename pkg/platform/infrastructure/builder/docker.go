@@ -0,0 +1,168 @@
+package builder
+
+import (
+	stdcontext "context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+var pushDigest = regexp.MustCompile(`digest: (sha256:[a-f0-9]{64})`)
+
+type dockerImageBuilder struct {
+	logger     applogger.Logger
+	runner     command.Runner
+	pushPolicy command.Policy
+	buildx     bool
+}
+
+func (builder dockerImageBuilder) Build(ctx stdcontext.Context, request ImageBuildRequest) (string, error) {
+	if len(request.Platforms) > 0 {
+		return builder.buildMultiPlatform(ctx, request)
+	}
+	args := []string{"build"}
+	if builder.buildx {
+		args = []string{"buildx", "build", "--load"}
+	}
+	args = append(args, request.Context)
+	args = append(args, commonBuildArgs(request)...)
+	args = append(args, builder.cacheArgs(request)...)
+	_, err := builder.runner.Execute(ctx, command.Command{
+		WorkDir:    request.WorkDir,
+		Executable: "docker",
+		Args:       args,
+		Env:        request.Env,
+		Verbose:    true,
+		Repository: request.Repository,
+		Phase:      service.PhaseImage,
+	})
+	return "", err
+}
+
+func (builder dockerImageBuilder) Push(
+	ctx stdcontext.Context,
+	repositoryID platform.RepositoryID,
+	workDir string,
+	reference string,
+) (string, error) {
+	result, err := builder.runner.Execute(ctx, builder.pushPolicy.Apply(command.Command{
+		WorkDir:    workDir,
+		Executable: "docker",
+		Args:       []string{"push", reference},
+		Verbose:    true,
+		Repository: repositoryID,
+		Phase:      service.PhasePush,
+	}))
+	if err != nil {
+		return "", err
+	}
+	matches := pushDigest.FindStringSubmatch(result.Stdout)
+	if matches == nil {
+		return "", fmt.Errorf("failed to find digest of pushed image %v", reference)
+	}
+	return matches[1], nil
+}
+
+// buildMultiPlatform always uses buildx, result can not be loaded to local image store,
+// so it is pushed as manifest list or stays only in build cache
+func (builder dockerImageBuilder) buildMultiPlatform(ctx stdcontext.Context, request ImageBuildRequest) (string, error) {
+	args := []string{
+		"buildx",
+		"build",
+		request.Context,
+		"--platform=" + strings.Join(request.Platforms, ","),
+	}
+	args = append(args, commonBuildArgs(request)...)
+	_, err := builder.runner.Execute(ctx, command.Command{
+		WorkDir:    request.WorkDir,
+		Executable: "docker",
+		Args:       append(append([]string(nil), args...), buildxCacheArgs(request)...),
+		Env:        request.Env,
+		Verbose:    true,
+		Repository: request.Repository,
+		Phase:      service.PhaseImage,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !request.Push {
+		builder.logger.Info(fmt.Sprintf("multi-platform image %v is kept only in build cache", strings.Join(request.Tags, ", ")))
+		return "", nil
+	}
+	return builder.pushMultiPlatform(ctx, request, args)
+}
+
+// pushMultiPlatform repeats build with push, all steps are served from build cache,
+// so only push gets timeout and retries of image push
+func (builder dockerImageBuilder) pushMultiPlatform(ctx stdcontext.Context, request ImageBuildRequest, args []string) (string, error) {
+	metadataFile, err := os.CreateTemp("", "metadata")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create temporary metadata file")
+	}
+	_ = metadataFile.Close()
+	defer os.Remove(metadataFile.Name())
+
+	_, err = builder.runner.Execute(ctx, builder.pushPolicy.Apply(command.Command{
+		WorkDir:    request.WorkDir,
+		Executable: "docker",
+		Args:       append(args, "--push", "--metadata-file="+metadataFile.Name()),
+		Env:        request.Env,
+		Verbose:    true,
+		Repository: request.Repository,
+		Phase:      service.PhasePush,
+	}))
+	if err != nil {
+		return "", err
+	}
+
+	metadataBody, err := os.ReadFile(metadataFile.Name())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to read buildx metadata file")
+	}
+	var metadata struct {
+		Digest string `json:"containerimage.digest"`
+	}
+	err = json.Unmarshal(metadataBody, &metadata)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to unmarshal buildx metadata file")
+	}
+	return metadata.Digest, nil
+}
+
+func (builder dockerImageBuilder) cacheArgs(request ImageBuildRequest) []string {
+	if builder.buildx {
+		return buildxCacheArgs(request)
+	}
+	// classic builder supports only inline cache from images
+	if len(request.CacheTo) > 0 {
+		builder.logger.Warning(
+			fmt.Errorf("cache export is not supported by %v backend", BackendDocker),
+			fmt.Sprintf("skip cache-to %v, use %v backend to export cache", strings.Join(request.CacheTo, ", "), BackendDockerBuildx),
+		)
+	}
+	result := make([]string, 0, len(request.CacheFrom))
+	for _, reference := range request.CacheFrom {
+		result = append(result, "--cache-from="+reference)
+	}
+	return result
+}
+
+func buildxCacheArgs(request ImageBuildRequest) []string {
+	result := make([]string, 0, len(request.CacheFrom)+len(request.CacheTo))
+	for _, reference := range request.CacheFrom {
+		result = append(result, "--cache-from=type=registry,ref="+reference)
+	}
+	for _, reference := range request.CacheTo {
+		result = append(result, fmt.Sprintf("--cache-to=type=registry,ref=%v,mode=max", reference))
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+// metadataRunner records commands and writes buildx metadata of pushed image
+type metadataRunner struct {
+	commands []command.Command
+}
+
+func (r *metadataRunner) Execute(_ context.Context, c command.Command) (command.Result, error) {
+	r.commands = append(r.commands, c)
+	for _, arg := range c.Args {
+		if metadataFile, ok := strings.CutPrefix(arg, "--metadata-file="); ok {
+			return command.Result{}, os.WriteFile(metadataFile, []byte(`{"containerimage.digest": "sha256:1"}`), 0o600)
+		}
+	}
+	return command.Result{}, nil
+}
+
+func TestBuildMultiPlatformPush(t *testing.T) {
+	runner := &metadataRunner{}
+	pushPolicy := command.Policy{Timeout: time.Minute, Retries: 3, Backoff: time.Second}
+	builder := dockerImageBuilder{logger: logger.NewTextLogger(), runner: runner, pushPolicy: pushPolicy, buildx: true}
+
+	digest, err := builder.Build(context.Background(), ImageBuildRequest{
+		Context:   ".",
+		Tags:      []string{"registry/app:1"},
+		Platforms: []string{"linux/amd64", "linux/arm64"},
+		Push:      true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if digest != "sha256:1" {
+		t.Errorf("expected digest of pushed image, got %q", digest)
+	}
+	if len(runner.commands) != 2 {
+		t.Fatalf("expected build and push, got %v commands", len(runner.commands))
+	}
+	build, push := runner.commands[0], runner.commands[1]
+	if build.Timeout != 0 || build.Retries != 0 || strings.Contains(strings.Join(build.Args, " "), "--push") {
+		t.Errorf("expected build without push policy, got %+v", build)
+	}
+	if push.Timeout != pushPolicy.Timeout || push.Retries != pushPolicy.Retries || !strings.Contains(strings.Join(push.Args, " "), "--push") {
+		t.Errorf("expected push with push policy, got %+v", push)
+	}
+}
@@ -0,0 +1,105 @@
+package builder
+
+import (
+	stdcontext "context"
+	"fmt"
+	"sort"
+	"time"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/build"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+const (
+	BackendDocker       = "docker"
+	BackendDockerBuildx = "docker-buildx"
+	BackendPodman       = "podman"
+	BackendBuildah      = "buildah"
+)
+
+// ImageBuildRequest is backend independent description of single image build
+type ImageBuildRequest struct {
+	// Repository selects run log of build
+	Repository string
+	WorkDir    string
+	Context    string
+	DockerFile string
+	// Tags contains full image references
+	Tags    []string
+	Args    map[string]string
+	Labels  map[string]string
+	Target  string
+	Secrets []build.Secret
+	// Env is environment of build command, secrets can reference its variables
+	Env map[string]string
+	// Platforms enables multi-platform build, such images can not be loaded locally and pushed while building
+	Platforms []string
+	// Push pushes multi-platform image as manifest list
+	Push bool
+	// CacheFrom and CacheTo contain registry cache references, backend translates them to own syntax
+	CacheFrom []string
+	CacheTo   []string
+}
+
+type ImageBuilder interface {
+	// Build builds image and returns digest when image was pushed while building
+	Build(ctx stdcontext.Context, request ImageBuildRequest) (string, error)
+	// Push pushes image reference and returns digest of pushed image
+	Push(ctx stdcontext.Context, repositoryID platform.RepositoryID, workDir string, reference string) (string, error)
+}
+
+// defaultPushPolicy of image push, can be overridden in platform config
+var defaultPushPolicy = command.Policy{Timeout: 30 * time.Minute, Retries: 3, Backoff: 10 * time.Second}
+
+func NewImageBuilder(
+	backend string,
+	logger applogger.Logger,
+	runner command.Runner,
+	pushPolicy platform.CommandPolicy,
+) (ImageBuilder, error) {
+	policy := defaultPushPolicy.Override(pushPolicy)
+	switch backend {
+	case "", BackendDocker:
+		return &dockerImageBuilder{logger: logger, runner: runner, pushPolicy: policy}, nil
+	case BackendDockerBuildx:
+		return &dockerImageBuilder{logger: logger, runner: runner, pushPolicy: policy, buildx: true}, nil
+	case BackendPodman, BackendBuildah:
+		return &containersImageBuilder{executable: backend, runner: runner, pushPolicy: policy}, nil
+	default:
+		return nil, fmt.Errorf("unknown image builder backend %v", backend)
+	}
+}
+
+// commonBuildArgs translates request to flags supported by all backends
+func commonBuildArgs(request ImageBuildRequest) []string {
+	result := []string{
+		fmt.Sprintf("--file=%v", request.DockerFile),
+	}
+	for _, tag := range request.Tags {
+		result = append(result, "--tag="+tag)
+	}
+	result = append(result, keyValueArgs("--build-arg", request.Args)...)
+	result = append(result, keyValueArgs("--label", request.Labels)...)
+	if request.Target != "" {
+		result = append(result, "--target="+request.Target)
+	}
+	for _, secret := range request.Secrets {
+		if secret.Env != "" {
+			result = append(result, fmt.Sprintf("--secret=id=%v,env=%v", secret.ID, secret.Env))
+		} else {
+			result = append(result, fmt.Sprintf("--secret=id=%v,src=%v", secret.ID, secret.File))
+		}
+	}
+	return result
+}
+
+func keyValueArgs(flag string, values map[string]string) []string {
+	result := make([]string, 0, len(values))
+	for key, value := range values {
+		result = append(result, fmt.Sprintf("%v=%v=%v", flag, key, value))
+	}
+	sort.Strings(result)
+	return result
+}
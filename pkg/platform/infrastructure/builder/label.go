@@ -0,0 +1,55 @@
+package builder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/build"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const (
+	ociLabelPrefix      = "org.opencontainers.image."
+	platformLabelPrefix = "io.tss-calculator.platform."
+)
+
+func buildLabels(
+	contextID platform.ContextID,
+	created time.Time,
+	image build.Image,
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+) map[string]string {
+	labels := map[string]string{
+		ociLabelPrefix + "revision":     repository.Commit,
+		ociLabelPrefix + "source":       repository.GitSrc,
+		ociLabelPrefix + "created":      created.UTC().Format(time.RFC3339),
+		platformLabelPrefix + "hash":    hex.EncodeToString(repository.Hash),
+		platformLabelPrefix + "context": contextID,
+	}
+	if version := imageVersion(repository.Tags); version != "" {
+		labels[ociLabelPrefix+"version"] = version
+	}
+	for _, depends := range transitiveDependencies(repository, repositories) {
+		labels[fmt.Sprintf("%vdependency.%v.hash", platformLabelPrefix, depends)] = hex.EncodeToString(repositories[depends].Hash)
+	}
+	for key, value := range image.Labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// imageVersion returns semver git tag of commit or any its git tag when there is no semver one
+func imageVersion(gitTags []string) string {
+	for _, tag := range gitTags {
+		if semverTag.MatchString(tag) {
+			return tag
+		}
+	}
+	if len(gitTags) > 0 {
+		return gitTags[0]
+	}
+	return ""
+}
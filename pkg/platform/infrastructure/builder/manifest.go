@@ -0,0 +1,80 @@
+package builder
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const buildManifestPath = ".platform/build-manifest.json"
+
+type PushedImage struct {
+	Reference string `json:"reference"`
+	Digest    string `json:"digest,omitempty"`
+}
+
+// ManifestRepository describes built repository, BuildArgs contains build args of every image by image name,
+// Images contains references built into local image store and Pushed contains references pushed to registry,
+// multi-platform images exist only in registry, so they are only in Pushed
+type ManifestRepository struct {
+	Commit    string                       `json:"commit"`
+	Hash      string                       `json:"hash"`
+	Branch    string                       `json:"branch,omitempty"`
+	BuildArgs map[string]map[string]string `json:"buildArgs"`
+	Images    []string                     `json:"images"`
+	Pushed    []PushedImage                `json:"pushed"`
+}
+
+type Manifest struct {
+	ContextID    string                                        `json:"context"`
+	Registry     string                                        `json:"registry"`
+	Repositories map[platform.RepositoryID]*ManifestRepository `json:"repositories"`
+}
+
+func newManifest() *Manifest {
+	return &Manifest{Repositories: make(map[platform.RepositoryID]*ManifestRepository)}
+}
+
+func (manifest *Manifest) reset(contextID platform.ContextID, registry string) {
+	manifest.ContextID = contextID
+	manifest.Registry = registry
+	manifest.Repositories = make(map[platform.RepositoryID]*ManifestRepository)
+}
+
+func (manifest *Manifest) repository(repository service.RepositoryInfo) *ManifestRepository {
+	result, ok := manifest.Repositories[repository.ID]
+	if ok {
+		return result
+	}
+	result = &ManifestRepository{
+		Commit:    repository.Commit,
+		Hash:      hex.EncodeToString(repository.Hash),
+		BuildArgs: map[string]map[string]string{},
+		Images:    []string{},
+		Pushed:    []PushedImage{},
+	}
+	if repository.Branch != nil {
+		result.Branch = *repository.Branch
+	}
+	manifest.Repositories[repository.ID] = result
+	return result
+}
+
+func (manifest *Manifest) write() error {
+	err := os.MkdirAll(path.Dir(buildManifestPath), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory for %v", buildManifestPath)
+	}
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal build manifest")
+	}
+	err = os.WriteFile(buildManifestPath, body, 0o600)
+	return errors.Wrapf(err, "failed to write build manifest %v", buildManifestPath)
+}
@@ -2,12 +2,12 @@ package builder
 
 import (
 	stdcontext "context"
-	"encoding/hex"
 	"fmt"
 	"strings"
 	"time"
 
 	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/build"
 	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
 	"github.com/tss-calculator/tools/pkg/platform/application/service"
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
@@ -19,47 +19,77 @@ func NewRepositoryBuilder(
 	configLoader *buildconfig.Loader,
 	repositoryProvider service.RepositoryProvider,
 	runner command.Runner,
+	imageBuilder ImageBuilder,
+	sourceCache *SourceCache,
+	imageTags []string,
+	buildCache platform.BuildCache,
+	buildArgNames platform.BuildArgNames,
+	events service.EventPublisher,
+	dryRun bool,
 ) service.RepositoryBuilder {
 	return &repositoryBuilder{
 		logger:             logger,
+		events:             events,
+		dryRun:             dryRun,
 		configLoader:       configLoader,
 		repositoryProvider: repositoryProvider,
 		runner:             runner,
+		imageBuilder:       imageBuilder,
+		sourceCache:        sourceCache,
+		tagPolicy:          newTagPolicy(imageTags),
+		cachePolicy:        newCachePolicy(buildCache),
+		buildArgNaming:     newBuildArgNaming(buildArgNames),
+		manifest:           newManifest(),
 	}
 }
 
 type repositoryBuilder struct {
-	logger             applogger.Logger
+	logger applogger.Logger
+	events service.EventPublisher
+	// dryRun skips staging of inputs, source cache, collecting of outputs and writing of manifest
+	dryRun             bool
 	configLoader       *buildconfig.Loader
 	repositoryProvider service.RepositoryProvider
 	runner             command.Runner
-}
-
-func (builder repositoryBuilder) Push(ctx stdcontext.Context, registry string, repositories map[platform.RepositoryID]service.RepositoryInfo) error {
-	for _, repository := range repositories {
-		err := builder.pushDockerImages(ctx, registry, repository)
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	imageBuilder       ImageBuilder
+	sourceCache        *SourceCache
+	tagPolicy          tagPolicy
+	cachePolicy        cachePolicy
+	buildArgNaming     buildArgNaming
+	manifest           *Manifest
 }
 
 func (builder repositoryBuilder) Build(
 	ctx stdcontext.Context,
+	contextID platform.ContextID,
 	registry string,
 	repositories map[platform.RepositoryID]service.RepositoryInfo,
+	pushImages bool,
 ) error {
+	err := builder.assertMultiPlatformImages(repositories, pushImages)
+	if err != nil {
+		return err
+	}
+	builder.manifest.reset(contextID, registry)
 	buildMap := make(map[platform.RepositoryID]struct{})
-	buildRepository := func(repository service.RepositoryInfo) error {
+	var buildRepository func(repository service.RepositoryInfo) error
+	buildRepository = func(repository service.RepositoryInfo) error {
 		if _, ok := buildMap[repository.ID]; ok {
 			return nil
 		}
-		err := builder.buildSources(ctx, repository)
+		for _, repositoryID := range repository.DependsOn {
+			err := buildRepository(repositories[repositoryID])
+			if err != nil {
+				return err
+			}
+		}
+		err := builder.step(repository.ID, service.PhaseSources, "sources", func() error {
+			return builder.buildSources(ctx, contextID, registry, repository, repositories)
+		})
 		if err != nil {
 			return err
 		}
-		err = builder.buildDockerImages(ctx, registry, repository, repositories)
+		err = builder.buildDockerImages(ctx, contextID, registry, repository, repositories, pushImages)
 		if err != nil {
 			return err
 		}
@@ -68,21 +98,68 @@ func (builder repositoryBuilder) Build(
 	}
 
 	for _, repository := range repositories {
-		for _, repositoryID := range repository.DependsOn {
-			err := buildRepository(repositories[repositoryID])
+		err := buildRepository(repository)
+		if err != nil {
+			return err
+		}
+	}
+	if pushImages {
+		for _, repository := range repositories {
+			err := builder.pushDockerImages(ctx, contextID, registry, repository)
 			if err != nil {
 				return err
 			}
 		}
-		err := buildRepository(repository)
+	}
+	return builder.writeManifest()
+}
+
+// assertMultiPlatformImages fails before build when dependents can not resolve multi-platform images,
+// such images are not loaded to local image store and exist only in registry after push
+func (builder repositoryBuilder) assertMultiPlatformImages(
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+	pushImages bool,
+) error {
+	dependents := make(map[platform.RepositoryID][]platform.RepositoryID)
+	for _, repository := range repositories {
+		for _, depends := range repository.DependsOn {
+			dependents[depends] = append(dependents[depends], repository.ID)
+		}
+	}
+	for id, repositoryDependents := range dependents {
+		repositoryPath := builder.repositoryProvider.RepositoryPath(id)
+		buildConfig, err := builder.configLoader.Load(repositoryPath + "/platform-build.json")
 		if err != nil {
 			return err
 		}
+		for _, image := range buildConfig.Images {
+			if len(image.Platforms) == 0 || (pushImages && !image.SkipPush) {
+				continue
+			}
+			return fmt.Errorf(
+				"multi-platform image %v of repository %v is not pushed, so its dependents %v can not use it, build with --push-images",
+				image.Name, id, strings.Join(repositoryDependents, ", "),
+			)
+		}
 	}
 	return nil
 }
 
-func (builder repositoryBuilder) buildSources(ctx stdcontext.Context, repository service.RepositoryInfo) error {
+func (builder repositoryBuilder) writeManifest() error {
+	if builder.dryRun {
+		builder.logger.Info("dry-run: skip write " + buildManifestPath)
+		return nil
+	}
+	return builder.manifest.write()
+}
+
+func (builder repositoryBuilder) buildSources(
+	ctx stdcontext.Context,
+	contextID platform.ContextID,
+	registry string,
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+) error {
 	builder.logger.Info(fmt.Sprintf("start build sources \"%v\"", repository.ID))
 	start := time.Now()
 	defer func() {
@@ -95,20 +172,47 @@ func (builder repositoryBuilder) buildSources(ctx stdcontext.Context, repository
 		return err
 	}
 
-	_, err = builder.runner.Execute(ctx, command.Command{
-		WorkDir:    repositoryPath,
-		Executable: buildConfig.Sources.Executable,
-		Args:       buildConfig.Sources.Args,
-		Verbose:    true,
-	})
-	return err
+	env, err := builder.commandEnv(registry, repository, repositories)
+	if err != nil {
+		return err
+	}
+	variables := newRepositoryVariables(contextID, registry, repository)
+	if builder.dryRun {
+		builder.logger.Info(fmt.Sprintf("dry-run: skip source cache, inputs and outputs of \"%v\"", repository.ID))
+		return builder.runCommands(ctx, repositoryPath, buildConfig.Sources, env, variables, service.PhaseSources)
+	}
+
+	restored, err := builder.sourceCache.Restore(repository, repositoryPath, buildConfig.Outputs)
+	if err != nil {
+		return err
+	}
+	if restored {
+		builder.logger.Info(fmt.Sprintf("sources \"%v\" restored from cache", repository.ID))
+		return builder.collectOutputs(repository, buildConfig)
+	}
+
+	err = builder.stageInputs(repository, repositories, buildConfig)
+	if err != nil {
+		return err
+	}
+	err = builder.runCommands(ctx, repositoryPath, buildConfig.Sources, env, variables, service.PhaseSources)
+	if err != nil {
+		return err
+	}
+	err = builder.sourceCache.Store(repository, repositoryPath, buildConfig.Outputs)
+	if err != nil {
+		return err
+	}
+	return builder.collectOutputs(repository, buildConfig)
 }
 
 func (builder repositoryBuilder) buildDockerImages(
 	ctx stdcontext.Context,
+	contextID platform.ContextID,
 	registry string,
 	repository service.RepositoryInfo,
 	repositories map[platform.RepositoryID]service.RepositoryInfo,
+	pushImages bool,
 ) error {
 	builder.logger.Info(fmt.Sprintf("start build docker images for \"%v\"", repository.ID))
 	start := time.Now()
@@ -122,43 +226,78 @@ func (builder repositoryBuilder) buildDockerImages(
 		return err
 	}
 
-	args := make(map[string]string)
-	args["REGISTRY"] = registry
-	for _, depends := range repository.DependsOn {
-		r := repositories[depends]
-		args[strings.ReplaceAll(depends, "-", "_")] = hex.EncodeToString(r.Hash)
+	args, err := builder.buildArgNaming.BuildArgs(registry, repository, repositories)
+	if err != nil {
+		return err
 	}
-
-	args[strings.ReplaceAll(repository.ID, "-", "_")] = hex.EncodeToString(repository.Hash)
+	imageTags, err := builder.tagPolicy.Tags(contextID, registry, repository)
+	if err != nil {
+		return err
+	}
+	manifestRepository := builder.manifest.repository(repository)
 	for _, image := range buildConfig.Images {
-		tags := []string{
-			"--tag=" + buildTag(registry, image.Name, hex.EncodeToString(repository.Hash)),
+		tags := make([]string, 0, len(imageTags))
+		for _, tag := range imageTags {
+			tags = append(tags, buildTag(registry, image.Name, tag))
 		}
-		if repository.Branch != nil {
-			tags = append(tags, "--tag="+buildTag(registry, image.Name, *repository.Branch))
+
+		cacheFrom, cacheTo, err2 := builder.cachePolicy.References(contextID, registry, image, repository)
+		if err2 != nil {
+			return err2
+		}
+		if !pushImages {
+			cacheTo = nil
 		}
 
-		dockerArgs := []string{
-			"build",
-			image.Context,
-			fmt.Sprintf("--file=%v", image.DockerFile),
+		imageArgs, err2 := imageBuildArgs(contextID, registry, image, repository, args)
+		if err2 != nil {
+			return err2
 		}
-		dockerArgs = append(dockerArgs, tags...)
-		dockerArgs = append(dockerArgs, buildArgs(args)...)
-		_, err = builder.runner.Execute(ctx, command.Command{
+		manifestRepository.BuildArgs[image.Name] = imageArgs
+
+		push := pushImages && !image.SkipPush
+		request := ImageBuildRequest{
 			WorkDir:    repositoryPath,
-			Executable: "docker",
-			Args:       dockerArgs,
-			Verbose:    true,
+			Context:    image.Context,
+			DockerFile: image.DockerFile,
+			Tags:       tags,
+			Args:       imageArgs,
+			Labels:     buildLabels(contextID, start, image, repository, repositories),
+			Target:     image.Target,
+			Repository: repository.ID,
+			Secrets:    image.Secrets,
+			Env:        repository.Env,
+			Platforms:  image.Platforms,
+			Push:       push,
+			CacheFrom:  cacheFrom,
+			CacheTo:    cacheTo,
+		}
+		var digest string
+		err2 = builder.step(repository.ID, service.PhaseImage, "build image "+image.Name, func() (buildErr error) {
+			digest, buildErr = builder.imageBuilder.Build(ctx, request)
+			return buildErr
 		})
-		if err != nil {
-			return err
+		if err2 != nil {
+			return err2
+		}
+		switch {
+		case len(image.Platforms) == 0:
+			manifestRepository.Images = append(manifestRepository.Images, tags...)
+		case push:
+			for _, tag := range tags {
+				builder.pushed(manifestRepository, repository.ID, tag, digest)
+			}
 		}
 	}
 	return nil
 }
 
-func (builder repositoryBuilder) pushDockerImages(ctx stdcontext.Context, registry string, repository service.RepositoryInfo) error {
+func (builder repositoryBuilder) pushDockerImages(
+	ctx stdcontext.Context,
+	contextID platform.ContextID,
+	registry string,
+	repository service.RepositoryInfo,
+) error {
 	builder.logger.Info(fmt.Sprintf("start push docker images for \"%v\"", repository.ID))
 	start := time.Now()
 	defer func() {
@@ -171,45 +310,59 @@ func (builder repositoryBuilder) pushDockerImages(ctx stdcontext.Context, regist
 		return err
 	}
 
+	imageTags, err := builder.tagPolicy.Tags(contextID, registry, repository)
+	if err != nil {
+		return err
+	}
+	manifestRepository := builder.manifest.repository(repository)
 	for _, image := range buildConfig.Images {
 		if image.SkipPush {
 			builder.logger.Info(fmt.Sprintf("skip push %v/%v", registry, image.Name))
 			continue
 		}
-		err = builder.pushDockerImage(ctx, repository.ID, buildTag(registry, image.Name, hex.EncodeToString(repository.Hash)))
-		if err != nil {
-			return err
+		if len(image.Platforms) > 0 {
+			builder.logger.Info(fmt.Sprintf("skip push %v/%v, multi-platform image is pushed while building", registry, image.Name))
+			continue
 		}
-		if repository.Branch != nil {
-			err = builder.pushDockerImage(ctx, repository.ID, buildTag(registry, image.Name, *repository.Branch))
-			if err != nil {
-				return err
+		for _, tag := range imageTags {
+			reference := buildTag(registry, image.Name, tag)
+			builder.logger.Info(fmt.Sprintf("push image %v", reference))
+			var digest string
+			err2 := builder.step(repository.ID, service.PhasePush, "push "+reference, func() (pushErr error) {
+				digest, pushErr = builder.imageBuilder.Push(ctx, repository.ID, repositoryPath, reference)
+				return pushErr
+			})
+			if err2 != nil {
+				return err2
 			}
+			builder.pushed(manifestRepository, repository.ID, reference, digest)
 		}
 	}
 	return nil
 }
 
-func (builder repositoryBuilder) pushDockerImage(ctx stdcontext.Context, repositoryID platform.RepositoryID, tag string) error {
-	builder.logger.Info(fmt.Sprintf("push image %v", tag))
-	_, err := builder.runner.Execute(ctx, command.Command{
-		WorkDir:    builder.repositoryProvider.RepositoryPath(repositoryID),
-		Executable: "docker",
-		Args: []string{
-			"push",
-			tag,
-		},
-		Verbose: true,
-	})
-	return err
-}
-
-func buildArgs(args map[string]string) []string {
-	result := make([]string, 0, len(args))
-	for key, value := range args {
-		result = append(result, fmt.Sprintf("--build-arg=%v=%v", strings.ToUpper(key), value))
+// imageBuildArgs renders image build args over common repository build args
+func imageBuildArgs(
+	contextID platform.ContextID,
+	registry string,
+	image build.Image,
+	repository service.RepositoryInfo,
+	commonArgs map[string]string,
+) (map[string]string, error) {
+	result := make(map[string]string, len(commonArgs)+len(image.BuildArgs))
+	for key, value := range commonArgs {
+		result[key] = value
+	}
+	variables := newRepositoryVariables(contextID, registry, repository)
+	variables.Image = image.Name
+	for key, expression := range image.BuildArgs {
+		value, err := executeTemplate("build arg", expression, variables)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
 	}
-	return result
+	return result, nil
 }
 
 func buildTag(registry, imageName, tag string) string {
@@ -0,0 +1,101 @@
+package builder
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/config/buildconfig"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/event"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/provider"
+)
+
+// digestImageBuilder returns digest for images pushed while building and for pushed references
+type digestImageBuilder struct{}
+
+func (digestImageBuilder) Build(_ context.Context, request ImageBuildRequest) (string, error) {
+	if request.Push {
+		return "sha256:built", nil
+	}
+	return "", nil
+}
+
+func (digestImageBuilder) Push(context.Context, platform.RepositoryID, string, string) (string, error) {
+	return "sha256:pushed", nil
+}
+
+func TestBuildManifest(t *testing.T) {
+	repoDir := t.TempDir()
+	err := os.MkdirAll(filepath.Join(repoDir, "app"), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(repoDir, "app", "platform-build.json"), []byte(`{"build": {"images": [
+		{"name": "app"},
+		{"name": "tools", "skipPush": true},
+		{"name": "multi", "platforms": ["linux/amd64", "linux/arm64"]}
+	]}}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	repositories := map[platform.RepositoryID]service.RepositoryInfo{
+		"app": {Repository: platform.Repository{ID: "app"}, Commit: "c1", Hash: []byte{1}},
+	}
+
+	testCases := []struct {
+		name       string
+		pushImages bool
+		images     []string
+		pushed     []PushedImage
+	}{
+		{
+			name:   "build only",
+			images: []string{"registry/app:01", "registry/tools:01"},
+			pushed: []PushedImage{},
+		},
+		{
+			name:       "build and push",
+			pushImages: true,
+			images:     []string{"registry/app:01", "registry/tools:01"},
+			pushed: []PushedImage{
+				{Reference: "registry/multi:01", Digest: "sha256:built"},
+				{Reference: "registry/app:01", Digest: "sha256:pushed"},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			log := logger.NewTextLogger()
+			builder := NewRepositoryBuilder(
+				log,
+				buildconfig.NewLoader(),
+				provider.NewRepositoryProvider(repoDir, nil, nil, nil),
+				nil,
+				digestImageBuilder{},
+				nil,
+				nil,
+				platform.BuildCache{CacheFrom: []string{}, CacheTo: []string{}},
+				platform.BuildArgNames{},
+				event.NewStream(log, false),
+				true,
+			).(*repositoryBuilder)
+
+			err := builder.Build(context.Background(), "default", "registry", repositories, testCase.pushImages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			manifestRepository := builder.manifest.Repositories["app"]
+			if !reflect.DeepEqual(manifestRepository.Images, testCase.images) {
+				t.Errorf("expected images %v, got %v", testCase.images, manifestRepository.Images)
+			}
+			if !reflect.DeepEqual(manifestRepository.Pushed, testCase.pushed) {
+				t.Errorf("expected pushed %+v, got %+v", testCase.pushed, manifestRepository.Pushed)
+			}
+		})
+	}
+}
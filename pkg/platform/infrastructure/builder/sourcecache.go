@@ -0,0 +1,211 @@
+package builder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+func NewSourceCache(logger applogger.Logger, dir string, maxSize int64) *SourceCache {
+	return &SourceCache{
+		dir:       dir,
+		maxSize:   maxSize,
+		entries:   entryDir{logger: logger, name: "source cache", dir: dir},
+		artifacts: entryDir{logger: logger, name: "artifacts", dir: artifactsDir},
+	}
+}
+
+// SourceCache stores declared outputs of source builds in <dir>/<repository>/<hash>,
+// entries are evicted by last usage time when cache exceeds max size,
+// artifacts directory has same layout and is evicted by same policy on prune
+type SourceCache struct {
+	dir       string
+	maxSize   int64
+	entries   entryDir
+	artifacts entryDir
+}
+
+// entryDir contains entries in <dir>/<repository>/<hash>, modification time of entry is its last usage time
+type entryDir struct {
+	logger applogger.Logger
+	name   string
+	dir    string
+}
+
+type sourceCacheEntry struct {
+	path   string
+	size   int64
+	usedAt time.Time
+}
+
+// Restore copies cached outputs into repository and reports whether cache entry was found
+func (cache *SourceCache) Restore(repository service.RepositoryInfo, repositoryPath string, outputs []string) (bool, error) {
+	entryPath := cache.entryPath(repository)
+	if len(outputs) == 0 || !exist(entryPath) {
+		return false, nil
+	}
+	for _, output := range outputs {
+		destination := path.Join(repositoryPath, output)
+		err := os.RemoveAll(destination)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to clean output %v", destination)
+		}
+		err = copyPath(path.Join(entryPath, output), destination)
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to restore output %v of repository %v", output, repository.ID)
+		}
+	}
+	now := time.Now()
+	return true, os.Chtimes(entryPath, now, now)
+}
+
+// Store copies outputs of repository into cache and evicts old entries
+func (cache *SourceCache) Store(repository service.RepositoryInfo, repositoryPath string, outputs []string) error {
+	if len(outputs) == 0 {
+		return nil
+	}
+	entryPath := cache.entryPath(repository)
+	err := os.MkdirAll(path.Dir(entryPath), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create source cache directory %v", path.Dir(entryPath))
+	}
+	tmpPath, err := os.MkdirTemp(path.Dir(entryPath), ".tmp")
+	if err != nil {
+		return errors.Wrap(err, "failed to create temporary source cache entry")
+	}
+	defer os.RemoveAll(tmpPath)
+	for _, output := range outputs {
+		err = copyPath(path.Join(repositoryPath, output), path.Join(tmpPath, output))
+		if err != nil {
+			return errors.Wrapf(err, "failed to cache output %v of repository %v", output, repository.ID)
+		}
+	}
+	err = os.RemoveAll(entryPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to clean source cache entry %v", entryPath)
+	}
+	err = os.Rename(tmpPath, entryPath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to store source cache entry %v", entryPath)
+	}
+	return cache.entries.evict(cache.maxSize)
+}
+
+// Prune evicts entries of cache and artifacts exceeding max size or all entries
+func (cache *SourceCache) Prune(all bool) error {
+	for _, entries := range []entryDir{cache.entries, cache.artifacts} {
+		var err error
+		if all {
+			err = entries.remove()
+		} else {
+			err = entries.evict(cache.maxSize)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (entries entryDir) remove() error {
+	entries.logger.Info(fmt.Sprintf("remove %v %v", entries.name, entries.dir))
+	return errors.Wrapf(os.RemoveAll(entries.dir), "failed to remove %v %v", entries.name, entries.dir)
+}
+
+func (entries entryDir) evict(maxSize int64) error {
+	if maxSize <= 0 {
+		return nil
+	}
+	list, err := entries.list()
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, entry := range list {
+		size += entry.size
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].usedAt.Before(list[j].usedAt)
+	})
+	for _, entry := range list {
+		if size <= maxSize {
+			break
+		}
+		entries.logger.Info(fmt.Sprintf("evict %v entry %v", entries.name, entry.path))
+		err = os.RemoveAll(entry.path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to evict %v entry %v", entries.name, entry.path)
+		}
+		size -= entry.size
+	}
+	return nil
+}
+
+func (entries entryDir) list() ([]sourceCacheEntry, error) {
+	repositories, err := os.ReadDir(entries.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read %v %v", entries.name, entries.dir)
+	}
+	var result []sourceCacheEntry
+	for _, repository := range repositories {
+		if !repository.IsDir() {
+			continue
+		}
+		hashes, err2 := os.ReadDir(path.Join(entries.dir, repository.Name()))
+		if err2 != nil {
+			return nil, errors.Wrapf(err2, "failed to read %v of repository %v", entries.name, repository.Name())
+		}
+		for _, hash := range hashes {
+			if strings.HasPrefix(hash.Name(), ".") {
+				continue
+			}
+			info, err3 := hash.Info()
+			if err3 != nil {
+				return nil, err3
+			}
+			entryPath := path.Join(entries.dir, repository.Name(), hash.Name())
+			size, err3 := dirSize(entryPath)
+			if err3 != nil {
+				return nil, err3
+			}
+			result = append(result, sourceCacheEntry{path: entryPath, size: size, usedAt: info.ModTime()})
+		}
+	}
+	return result, nil
+}
+
+func (cache *SourceCache) entryPath(repository service.RepositoryInfo) string {
+	return path.Join(cache.dir, repository.ID, hex.EncodeToString(repository.Hash))
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Type().IsRegular() {
+			info, infoErr := entry.Info()
+			if infoErr != nil {
+				return infoErr
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
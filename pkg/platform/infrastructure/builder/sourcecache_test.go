@@ -0,0 +1,72 @@
+package builder
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+)
+
+// writeEntry writes entry of 10 bytes used at given time
+func writeEntry(t *testing.T, entryPath string, usedAt time.Time) {
+	t.Helper()
+	err := os.MkdirAll(entryPath, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path.Join(entryPath, "out"), []byte("0123456789"), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chtimes(entryPath, usedAt, usedAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPruneEvictsArtifacts(t *testing.T) {
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(previous)
+	})
+
+	cacheDir := ".platform/cache/sources"
+	now := time.Now()
+	for _, dir := range []string{cacheDir, artifactsDir} {
+		writeEntry(t, path.Join(dir, "a", "old"), now.Add(-time.Hour))
+		writeEntry(t, path.Join(dir, "a", "new"), now)
+	}
+	cache := NewSourceCache(logger.NewTextLogger(), cacheDir, 15)
+
+	err = cache.Prune(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{cacheDir, artifactsDir} {
+		if exist(path.Join(dir, "a", "old")) {
+			t.Errorf("expected least recently used entry of %v to be evicted", dir)
+		}
+		if !exist(path.Join(dir, "a", "new")) {
+			t.Errorf("expected recently used entry of %v to be kept", dir)
+		}
+	}
+
+	err = cache.Prune(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{cacheDir, artifactsDir} {
+		if exist(dir) {
+			t.Errorf("expected %v to be removed", dir)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package builder
+
+import (
+	stdcontext "context"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/build"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+const envPrefix = "PLATFORM_"
+
+var invalidEnvSymbols = regexp.MustCompile(`[^A-Z0-9_]`)
+
+type commandVariables struct {
+	repositoryVariables
+	Directory string
+	Env       map[string]string
+}
+
+// commandEnv returns repository env with standard variables passed to every repository command,
+// paths are absolute because commands run in repository or its work directory
+func (builder repositoryBuilder) commandEnv(
+	registry string,
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+) (map[string]string, error) {
+	env := make(map[string]string, len(repository.Env))
+	for key, value := range repository.Env {
+		env[key] = value
+	}
+	env[envPrefix+"REGISTRY"] = registry
+	env[envPrefix+"REPOSITORY"] = repository.ID
+	env[envPrefix+"HASH"] = hex.EncodeToString(repository.Hash)
+	env[envPrefix+"COMMIT"] = repository.Commit
+	repositoryPath, err := filepath.Abs(builder.repositoryProvider.RepositoryPath(repository.ID))
+	if err != nil {
+		return nil, err
+	}
+	env[envPrefix+"REPOSITORY_PATH"] = repositoryPath
+	// dependencies describe origin of each dependency variable to report collisions of sanitized ids
+	dependencies := make(map[string]platform.RepositoryID)
+	for _, depends := range transitiveDependencies(repository, repositories) {
+		name := envPrefix + "DEPENDENCY_" + invalidEnvSymbols.ReplaceAllString(strings.ToUpper(depends), "_")
+		if existing, ok := dependencies[name]; ok {
+			return nil, fmt.Errorf("env %v for dependency %v collides with dependency %v", name, depends, existing)
+		}
+		dependencies[name] = depends
+		dependencyPath, err2 := filepath.Abs(builder.repositoryProvider.RepositoryPath(depends))
+		if err2 != nil {
+			return nil, err2
+		}
+		env[name+"_HASH"] = hex.EncodeToString(repositories[depends].Hash)
+		env[name+"_PATH"] = dependencyPath
+	}
+	return env, nil
+}
+
+func (builder repositoryBuilder) runCommands(
+	ctx stdcontext.Context,
+	repositoryPath string,
+	commands []build.Command,
+	env map[string]string,
+	variables repositoryVariables,
+	phase string,
+) error {
+	for _, c := range commands {
+		commandEnv := make(map[string]string, len(env)+len(c.Env))
+		for key, value := range env {
+			commandEnv[key] = value
+		}
+		for key, value := range c.Env {
+			commandEnv[key] = value
+		}
+
+		if c.Condition != "" {
+			condition, err := executeTemplate("condition", c.Condition, commandVariables{
+				repositoryVariables: variables,
+				Directory:           repositoryPath,
+				Env:                 commandEnv,
+			})
+			if err != nil {
+				return err
+			}
+			if strings.TrimSpace(condition) != "true" {
+				builder.logger.Info(fmt.Sprintf("skip %v %v, condition is %v", c.Executable, strings.Join(c.Args, " "), strings.TrimSpace(condition)))
+				continue
+			}
+		}
+
+		_, err := builder.runner.Execute(ctx, command.Command{
+			WorkDir:    path.Join(repositoryPath, c.WorkDir),
+			Executable: c.Executable,
+			Args:       c.Args,
+			Env:        commandEnv,
+			Verbose:    true,
+			Repository: variables.RepositoryID,
+			Phase:      phase,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package builder
+
+import (
+	"errors"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+// step runs f and publishes its start and finish as repository step events
+func (builder repositoryBuilder) step(repositoryID platform.RepositoryID, phase string, name string, f func() error) error {
+	start := time.Now()
+	builder.events.Publish(service.Event{
+		Type:         service.EventStepStarted,
+		Time:         start,
+		RepositoryID: repositoryID,
+		Name:         name,
+		Phase:        phase,
+	})
+	err := f()
+	builder.events.Publish(service.Event{
+		Type:         service.EventStepFinished,
+		Time:         time.Now(),
+		RepositoryID: repositoryID,
+		Name:         name,
+		Phase:        phase,
+		Duration:     time.Since(start),
+		Skipped:      errors.Is(err, service.ErrTargetNotFound),
+		Err:          err,
+	})
+	return err
+}
+
+// pushed adds pushed image to manifest and publishes it
+func (builder repositoryBuilder) pushed(
+	manifestRepository *ManifestRepository,
+	repositoryID platform.RepositoryID,
+	reference string,
+	digest string,
+) {
+	manifestRepository.Pushed = append(manifestRepository.Pushed, PushedImage{
+		Reference: reference,
+		Digest:    digest,
+	})
+	builder.events.Publish(service.Event{
+		Type:         service.EventImagePushed,
+		Time:         time.Now(),
+		RepositoryID: repositoryID,
+		Image:        reference,
+		Digest:       digest,
+	})
+}
@@ -0,0 +1,114 @@
+package builder
+
+import (
+	"encoding/hex"
+	"regexp"
+	"strings"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const (
+	maxTagLength   = 128
+	shortSHALength = 7
+)
+
+var defaultImageTags = []string{
+	"{{.Hash}}",
+	"{{.Branch}}",
+}
+
+var (
+	invalidTagSymbols = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+	semverTag         = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
+)
+
+// repositoryVariables are available in image tag and build arg expressions
+type repositoryVariables struct {
+	ContextID    string
+	RepositoryID string
+	Registry     string
+	Hash         string
+	Commit       string
+	ShortSHA     string
+	Branch       string
+	GitTags      []string
+	SemverTags   []string
+	// Image is set only for build arg expressions
+	Image string
+}
+
+func newTagPolicy(expressions []string) tagPolicy {
+	if len(expressions) == 0 {
+		expressions = defaultImageTags
+	}
+	return tagPolicy{expressions: expressions}
+}
+
+// tagPolicy renders image tags from go-template expressions,
+// each expression may produce several whitespace separated tags or nothing at all
+type tagPolicy struct {
+	expressions []string
+}
+
+func (policy tagPolicy) Tags(
+	contextID platform.ContextID,
+	registry string,
+	repository service.RepositoryInfo,
+) ([]string, error) {
+	variables := newRepositoryVariables(contextID, registry, repository)
+	tagSet := make(map[string]struct{})
+	tags := make([]string, 0, len(policy.expressions))
+	for _, expression := range policy.expressions {
+		result, err := executeTemplate("image tag", expression, variables)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range strings.Fields(result) {
+			tag = sanitizeTag(tag)
+			if _, ok := tagSet[tag]; ok || tag == "" {
+				continue
+			}
+			tagSet[tag] = struct{}{}
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func newRepositoryVariables(contextID platform.ContextID, registry string, repository service.RepositoryInfo) repositoryVariables {
+	variables := repositoryVariables{
+		ContextID:    contextID,
+		RepositoryID: repository.ID,
+		Registry:     registry,
+		Hash:         hex.EncodeToString(repository.Hash),
+		Commit:       repository.Commit,
+		ShortSHA:     repository.Commit,
+		GitTags:      repository.Tags,
+	}
+	if len(variables.ShortSHA) > shortSHALength {
+		variables.ShortSHA = variables.ShortSHA[:shortSHALength]
+	}
+	if repository.Branch != nil {
+		variables.Branch = *repository.Branch
+	}
+	for _, tag := range repository.Tags {
+		if semverTag.MatchString(tag) {
+			variables.SemverTags = append(variables.SemverTags, strings.TrimPrefix(tag, "v"))
+		}
+	}
+	return variables
+}
+
+// sanitizeTag converts value to valid OCI reference tag: [A-Za-z0-9_][A-Za-z0-9_.-]{0,127}
+func sanitizeTag(tag string) string {
+	tag = invalidTagSymbols.ReplaceAllString(tag, "-")
+	if tag != "" && (tag[0] == '.' || tag[0] == '-') {
+		tag = "_" + tag[1:]
+	}
+	if len(tag) > maxTagLength {
+		tag = tag[:maxTagLength]
+	}
+	return tag
+}
@@ -0,0 +1,71 @@
+package builder
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSanitizeTag(t *testing.T) {
+	for tag, expected := range map[string]string{
+		"feature/x":                    "feature-x",
+		"feature/JIRA-1@fix":           "feature-JIRA-1-fix",
+		".hidden":                      "_hidden",
+		"-dash":                        "_dash",
+		"v1.2.3":                       "v1.2.3",
+		"":                             "",
+		strings.Repeat("a", 200):       strings.Repeat("a", maxTagLength),
+		"/" + strings.Repeat("b", 130): "_" + strings.Repeat("b", maxTagLength-1),
+	} {
+		if result := sanitizeTag(tag); result != expected {
+			t.Errorf("sanitizeTag(%q): expected %q, got %q", tag, expected, result)
+		}
+	}
+}
+
+func TestTagPolicy(t *testing.T) {
+	branch := "feature/x"
+	for _, testCase := range []struct {
+		name        string
+		expressions []string
+		branch      *string
+		gitTags     []string
+		expected    []string
+	}{
+		{
+			name:     "default tags",
+			branch:   &branch,
+			expected: []string{"0102", "feature-x"},
+		},
+		{
+			name:     "empty branch of detached checkout",
+			expected: []string{"0102"},
+		},
+		{
+			name:        "semver tags",
+			expressions: []string{"{{.Hash}}", "{{range .SemverTags}}{{.}} {{end}}"},
+			gitTags:     []string{"v1.2.3", "1.2.3", "release", "v2.0.0-rc.1"},
+			expected:    []string{"0102", "1.2.3", "2.0.0-rc.1"},
+		},
+		{
+			name:        "duplicate and empty tags",
+			expressions: []string{"{{.ContextID}}", "{{.Branch}}", "{{.ContextID}}", " "},
+			branch:      &branch,
+			expected:    []string{"feature-x"},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			repository := testRepository("app", 0x01, nil)
+			repository.Hash = []byte{0x01, 0x02}
+			repository.Branch = testCase.branch
+			repository.Tags = testCase.gitTags
+			tags, err := newTagPolicy(testCase.expressions).Tags("feature/x", "registry", repository)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tags, testCase.expected) {
+				t.Errorf("expected %v, got %v", testCase.expected, tags)
+			}
+		})
+	}
+}
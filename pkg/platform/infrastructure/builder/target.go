@@ -0,0 +1,61 @@
+package builder
+
+import (
+	stdcontext "context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+func (builder repositoryBuilder) RunTarget(
+	ctx stdcontext.Context,
+	contextID platform.ContextID,
+	registry string,
+	target string,
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+) error {
+	return builder.step(repository.ID, service.PhaseTarget, "target "+target, func() error {
+		return builder.runTarget(ctx, contextID, registry, target, repository, repositories)
+	})
+}
+
+func (builder repositoryBuilder) runTarget(
+	ctx stdcontext.Context,
+	contextID platform.ContextID,
+	registry string,
+	target string,
+	repository service.RepositoryInfo,
+	repositories map[platform.RepositoryID]service.RepositoryInfo,
+) error {
+	repositoryPath := builder.repositoryProvider.RepositoryPath(repository.ID)
+	buildConfig, err := builder.configLoader.Load(repositoryPath + "/platform-build.json")
+	if errors.Is(err, os.ErrNotExist) {
+		return errors.Wrapf(service.ErrTargetNotFound, "repository %v has no build config", repository.ID)
+	}
+	if err != nil {
+		return err
+	}
+	commands, ok := buildConfig.Targets[target]
+	if !ok {
+		return errors.Wrapf(service.ErrTargetNotFound, "target %v is not defined in repository %v", target, repository.ID)
+	}
+
+	env, err := builder.commandEnv(registry, repository, repositories)
+	if err != nil {
+		return err
+	}
+	builder.logger.Info(fmt.Sprintf("start target %v for \"%v\"", target, repository.ID))
+	return builder.runCommands(
+		ctx,
+		repositoryPath,
+		commands,
+		env,
+		newRepositoryVariables(contextID, registry, repository),
+		service.PhaseTarget+"-"+target,
+	)
+}
@@ -0,0 +1,36 @@
+package builder
+
+import (
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+var templateFunctions = template.FuncMap{
+	"ToUpper":     strings.ToUpper,
+	"ToLower":     strings.ToLower,
+	"Replace":     strings.ReplaceAll,
+	"TrimPrefix":  strings.TrimPrefix,
+	"SanitizeTag": sanitizeTag,
+	"Exist":       exist,
+}
+
+func executeTemplate(name, expression string, variables interface{}) (string, error) {
+	t, err := template.New(name).Funcs(templateFunctions).Parse(expression)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to parse %v expression %v", name, expression)
+	}
+	var result strings.Builder
+	err = t.Execute(&result, variables)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to execute %v expression %v", name, expression)
+	}
+	return result.String(), nil
+}
+
+func exist(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
@@ -0,0 +1,151 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+)
+
+// readOnlyGitCommands do not change repositories, so dry-run executes them to keep plan accurate
+var readOnlyGitCommands = map[string]bool{
+	"rev-parse":  true,
+	"status":     true,
+	"log":        true,
+	"show":       true,
+	"diff":       true,
+	"ls-files":   true,
+	"ls-remote":  true,
+	"merge-base": true,
+	"describe":   true,
+	"cat-file":   true,
+}
+
+const (
+	dryRunDigest   = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	metadataFlag   = "--metadata-file="
+	digestFileFlag = "--digestfile="
+)
+
+// imageTools push images and print digest of pushed image
+var imageTools = map[string]bool{
+	"docker":  true,
+	"podman":  true,
+	"buildah": true,
+}
+
+// NewDryRunRunner creates runner which prints commands instead of running them,
+// read-only git queries are executed by runner and get canned responses when they fail
+func NewDryRunRunner(logger applogger.Logger, runner Runner) Runner {
+	return &dryRunRunner{
+		logger: logger,
+		runner: runner,
+	}
+}
+
+type dryRunRunner struct {
+	logger applogger.Logger
+	runner Runner
+}
+
+func (r dryRunRunner) Execute(ctx context.Context, command Command) (Result, error) {
+	if readOnly(command) {
+		result, err := r.runner.Execute(ctx, command)
+		if err == nil {
+			return result, nil
+		}
+		r.logger.Debug(fmt.Sprintf("dry-run: use canned response, %v", err))
+		return cannedResult(command), nil
+	}
+
+	r.logger.Info("dry-run: " + FormatCommand(command))
+	if len(command.Env) > 0 {
+		keys := make([]string, 0, len(command.Env))
+		for key := range command.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		r.logger.Info("dry-run:   env " + strings.Join(keys, " "))
+	}
+	if command.Stdin != nil {
+		stdin, err := io.ReadAll(command.Stdin)
+		if err != nil {
+			return Result{ExitCode: -1}, err
+		}
+		for _, line := range strings.Split(strings.TrimRight(string(stdin), "\n"), "\n") {
+			r.logger.Info("dry-run:   | " + line)
+		}
+	}
+	err := writeMetadata(command)
+	if err != nil {
+		return Result{ExitCode: -1}, err
+	}
+	return cannedResult(command), nil
+}
+
+// writeMetadata writes canned digest to buildx metadata file of docker and digest file of podman and buildah push,
+// so callers read it as after real build or push
+func writeMetadata(command Command) error {
+	if !imageTools[command.Executable] {
+		return nil
+	}
+	for _, arg := range command.Args {
+		if metadataFile, ok := strings.CutPrefix(arg, metadataFlag); ok && command.Executable == "docker" {
+			metadata := fmt.Sprintf("{\"containerimage.digest\": %q}\n", dryRunDigest)
+			return os.WriteFile(metadataFile, []byte(metadata), 0o600)
+		}
+		if digestFile, ok := strings.CutPrefix(arg, digestFileFlag); ok {
+			return os.WriteFile(digestFile, []byte(dryRunDigest), 0o600)
+		}
+	}
+	return nil
+}
+
+// FormatCommand formats command as shell command line
+func FormatCommand(command Command) string {
+	parts := make([]string, 0, len(command.Args)+1)
+	parts = append(parts, quote(command.Executable))
+	for _, arg := range command.Args {
+		parts = append(parts, quote(arg))
+	}
+	line := strings.Join(parts, " ")
+	if command.WorkDir != "" {
+		line = fmt.Sprintf("(cd %v && %v)", quote(command.WorkDir), line)
+	}
+	return line
+}
+
+func readOnly(command Command) bool {
+	if command.Executable != "git" || len(command.Args) == 0 {
+		return false
+	}
+	if command.Args[0] == "tag" {
+		return len(command.Args) > 1 && (command.Args[1] == "--points-at" || command.Args[1] == "--list")
+	}
+	return readOnlyGitCommands[command.Args[0]]
+}
+
+func cannedResult(command Command) Result {
+	result := Result{}
+	switch {
+	case command.Executable == "git" && len(command.Args) > 1 && command.Args[0] == "rev-parse" && command.Args[1] == "--abbrev-ref":
+		result.Stdout = "HEAD\n"
+	case command.Executable == "git" && len(command.Args) > 0 && command.Args[0] == "rev-parse":
+		result.Stdout = strings.Repeat("0", 40) + "\n"
+	case imageTools[command.Executable] && len(command.Args) > 0 && command.Args[0] == "push":
+		result.Stdout = "dry-run: digest: " + dryRunDigest + " size: 0\n"
+	}
+	return result
+}
+
+func quote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\$`|&;<>()*?[]{}~#") {
+		return arg
+	}
+	return strconv.Quote(arg)
+}
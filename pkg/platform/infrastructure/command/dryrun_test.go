@@ -0,0 +1,82 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+)
+
+type fakeRunner struct {
+	executed []string
+}
+
+func (r *fakeRunner) Execute(_ context.Context, command Command) (Result, error) {
+	r.executed = append(r.executed, FormatCommand(command))
+	return Result{ExitCode: 128}, errors.New("not a git repository")
+}
+
+func TestDryRunRunner(t *testing.T) {
+	runner := &fakeRunner{}
+	r := NewDryRunRunner(logger.NewTextLogger(), runner)
+
+	for _, args := range [][]string{{"fetch"}, {"push", "--force"}, {"rev-parse", "HEAD"}, {"tag", "--points-at", "HEAD"}} {
+		_, err := r.Execute(context.Background(), Command{WorkDir: "src/a", Executable: "git", Args: args})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := []string{"(cd src/a && git rev-parse HEAD)", "(cd src/a && git tag --points-at HEAD)"}
+	if strings.Join(runner.executed, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected only read-only queries to be executed, got %v", runner.executed)
+	}
+
+	result, err := r.Execute(context.Background(), Command{Executable: "git", Args: []string{"rev-parse", "HEAD"}})
+	if err != nil || strings.TrimSpace(result.Stdout) != strings.Repeat("0", 40) {
+		t.Errorf("expected canned commit, got %q, %v", result.Stdout, err)
+	}
+}
+
+func TestDryRunRunnerCannedImageResults(t *testing.T) {
+	r := NewDryRunRunner(logger.NewTextLogger(), &fakeRunner{})
+
+	result, err := r.Execute(context.Background(), Command{Executable: "git", Args: []string{"push", "origin"}})
+	if err != nil || strings.Contains(result.Stdout, dryRunDigest) {
+		t.Errorf("expected no digest for git push, got %q, %v", result.Stdout, err)
+	}
+	result, err = r.Execute(context.Background(), Command{Executable: "docker", Args: []string{"push", "registry/a:1"}})
+	if err != nil || !strings.Contains(result.Stdout, "digest: "+dryRunDigest) {
+		t.Errorf("expected canned digest for docker push, got %q, %v", result.Stdout, err)
+	}
+
+	metadataFile := t.TempDir() + "/metadata.json"
+	_, err = r.Execute(context.Background(), Command{
+		Executable: "docker",
+		Args:       []string{"buildx", "build", ".", "--push", metadataFlag + metadataFile},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata, err := os.ReadFile(metadataFile)
+	if err != nil || !strings.Contains(string(metadata), dryRunDigest) {
+		t.Errorf("expected canned metadata, got %q, %v", metadata, err)
+	}
+
+	for _, executable := range []string{"podman", "buildah"} {
+		digestFile := t.TempDir() + "/digest"
+		_, err = r.Execute(context.Background(), Command{
+			Executable: executable,
+			Args:       []string{"push", digestFileFlag + digestFile, "registry/a:1"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		digest, err2 := os.ReadFile(digestFile)
+		if err2 != nil || string(digest) != dryRunDigest {
+			t.Errorf("expected canned digest file of %v, got %q, %v", executable, digest, err2)
+		}
+	}
+}
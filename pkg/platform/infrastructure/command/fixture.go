@@ -0,0 +1,208 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Invocation is recorded command with its result
+type Invocation struct {
+	WorkDir    string   `json:"workDir,omitempty"`
+	Executable string   `json:"executable"`
+	Args       []string `json:"args,omitempty"`
+	Stdin      string   `json:"stdin,omitempty"`
+	Stdout     string   `json:"stdout,omitempty"`
+	Stderr     string   `json:"stderr,omitempty"`
+	ExitCode   int      `json:"exitCode"`
+	Error      string   `json:"error,omitempty"`
+	// ErrorKind restores type of error on replay, so callers handle exit codes, timeouts and interrupts as in live run
+	ErrorKind string `json:"errorKind,omitempty"`
+}
+
+// Kinds of recorded errors, other errors are replayed as plain errors
+const (
+	ErrorKindExit        = "exit"
+	ErrorKindTimeout     = "timeout"
+	ErrorKindInterrupted = "interrupted"
+)
+
+type fixture struct {
+	Invocations []Invocation `json:"invocations"`
+}
+
+// NewRecordingRunner creates runner which executes commands by runner and writes invocations to fixture file
+func NewRecordingRunner(runner Runner, fixturePath string) Runner {
+	return &recordingRunner{
+		runner:      runner,
+		fixturePath: fixturePath,
+	}
+}
+
+type recordingRunner struct {
+	runner      Runner
+	fixturePath string
+
+	mu          sync.Mutex
+	invocations []Invocation
+}
+
+func (r *recordingRunner) Execute(ctx context.Context, command Command) (Result, error) {
+	invocation := Invocation{
+		WorkDir:    command.WorkDir,
+		Executable: command.Executable,
+		Args:       command.Args,
+	}
+	if command.Stdin != nil {
+		stdin, err := io.ReadAll(command.Stdin)
+		if err != nil {
+			return Result{ExitCode: -1}, err
+		}
+		invocation.Stdin = string(stdin)
+		command.Stdin = strings.NewReader(invocation.Stdin)
+	}
+	result, err := r.runner.Execute(ctx, command)
+	invocation.Stdout = result.Stdout
+	invocation.Stderr = result.Stderr
+	invocation.ExitCode = result.ExitCode
+	if err != nil {
+		invocation.Error = err.Error()
+		invocation.ErrorKind = errorKind(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.invocations = append(r.invocations, invocation)
+	// fixture is rewritten after every command, so it is complete even when platform fails
+	body, marshalErr := json.MarshalIndent(fixture{Invocations: r.invocations}, "", "  ")
+	if marshalErr != nil {
+		return result, errors.Join(err, marshalErr)
+	}
+	return result, errors.Join(err, os.WriteFile(r.fixturePath, body, 0o600))
+}
+
+// NewReplayRunner creates runner which serves results of invocations from fixture file without running commands,
+// equal invocations are served in recorded order
+func NewReplayRunner(fixturePath string) (*ReplayRunner, error) {
+	body, err := os.ReadFile(fixturePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture %v: %w", fixturePath, err)
+	}
+	var f fixture
+	err = json.Unmarshal(body, &f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal fixture %v: %w", fixturePath, err)
+	}
+	invocations := make(map[string][]Invocation)
+	for _, invocation := range f.Invocations {
+		key := invocationKey(invocation.WorkDir, invocation.Executable, invocation.Args, invocation.Stdin)
+		invocations[key] = append(invocations[key], invocation)
+	}
+	return &ReplayRunner{invocations: invocations}, nil
+}
+
+type ReplayRunner struct {
+	mu          sync.Mutex
+	invocations map[string][]Invocation
+}
+
+func (r *ReplayRunner) Execute(_ context.Context, command Command) (Result, error) {
+	var stdin string
+	if command.Stdin != nil {
+		body, err := io.ReadAll(command.Stdin)
+		if err != nil {
+			return Result{ExitCode: -1}, err
+		}
+		stdin = string(body)
+	}
+	key := invocationKey(command.WorkDir, command.Executable, command.Args, stdin)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	invocations := r.invocations[key]
+	if len(invocations) == 0 {
+		return Result{ExitCode: -1}, fmt.Errorf("no recorded invocation of %v", FormatCommand(command))
+	}
+	invocation := invocations[0]
+	r.invocations[key] = invocations[1:]
+
+	result := Result{
+		Stdout:   invocation.Stdout,
+		Stderr:   invocation.Stderr,
+		ExitCode: invocation.ExitCode,
+	}
+	if invocation.Error != "" {
+		return result, restoreError(invocation)
+	}
+	return result, nil
+}
+
+func errorKind(err error) string {
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(err, ErrTimeout):
+		return ErrorKindTimeout
+	case errors.Is(err, ErrInterrupted):
+		return ErrorKindInterrupted
+	case errors.As(err, &exitErr):
+		return ErrorKindExit
+	default:
+		return ""
+	}
+}
+
+// replayedError is recorded error with its original message, it wraps error of recorded kind
+type replayedError struct {
+	message string
+	err     error
+}
+
+func (e replayedError) Error() string {
+	return e.message
+}
+
+func (e replayedError) Unwrap() error {
+	return e.err
+}
+
+func restoreError(invocation Invocation) error {
+	var err error
+	switch invocation.ErrorKind {
+	case ErrorKindExit:
+		// process state is not recorded, exit code is in result
+		err = &exec.ExitError{}
+	case ErrorKindTimeout:
+		err = ErrTimeout
+	case ErrorKindInterrupted:
+		err = ErrInterrupted
+	default:
+		return errors.New(invocation.Error)
+	}
+	return replayedError{message: invocation.Error, err: err}
+}
+
+// Unused returns recorded invocations which were not replayed
+func (r *ReplayRunner) Unused() []Invocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []Invocation
+	for _, invocations := range r.invocations {
+		result = append(result, invocations...)
+	}
+	return result
+}
+
+// invocationKey identifies invocation by command line and stdin, so changed script passed by stdin is not replayed
+func invocationKey(workDir string, executable string, args []string, stdin string) string {
+	key := FormatCommand(Command{WorkDir: workDir, Executable: executable, Args: args})
+	if stdin != "" {
+		key += " <<< " + strconv.Quote(stdin)
+	}
+	return key
+}
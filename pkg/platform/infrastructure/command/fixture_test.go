@@ -0,0 +1,93 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	fixturePath := t.TempDir() + "/fixture.json"
+	recorder := NewRecordingRunner(NewCommandRunner(logger.NewTextLogger(), true, time.Second, nil), fixturePath)
+	for _, word := range []string{"first", "second"} {
+		_, err := recorder.Execute(context.Background(), Command{Executable: "echo", Args: []string{"hello"}, Env: map[string]string{"W": word}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	replay, err := NewReplayRunner(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		result, err2 := replay.Execute(context.Background(), Command{Executable: "echo", Args: []string{"hello"}})
+		if err2 != nil || result.Stdout != "hello\n" {
+			t.Errorf("unexpected replay %+v, %v", result, err2)
+		}
+	}
+	_, err = replay.Execute(context.Background(), Command{Executable: "echo", Args: []string{"hello"}})
+	if err == nil {
+		t.Error("expected error for not recorded invocation")
+	}
+}
+
+func TestReplayMatchesStdin(t *testing.T) {
+	fixturePath := t.TempDir() + "/fixture.json"
+	recorder := NewRecordingRunner(NewCommandRunner(logger.NewTextLogger(), true, time.Second, nil), fixturePath)
+	_, err := recorder.Execute(context.Background(), Command{Executable: "bash", Stdin: strings.NewReader("echo first\n")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	replay, err := NewReplayRunner(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = replay.Execute(context.Background(), Command{Executable: "bash", Stdin: strings.NewReader("echo second\n")})
+	if err == nil {
+		t.Error("expected error for changed stdin")
+	}
+	result, err := replay.Execute(context.Background(), Command{Executable: "bash", Stdin: strings.NewReader("echo first\n")})
+	if err != nil || result.Stdout != "first\n" {
+		t.Errorf("unexpected replay %+v, %v", result, err)
+	}
+}
+
+func TestReplayRestoresErrorKind(t *testing.T) {
+	fixturePath := t.TempDir() + "/fixture.json"
+	recorder := NewRecordingRunner(NewCommandRunner(logger.NewTextLogger(), true, time.Second, nil), fixturePath)
+	for _, c := range []Command{
+		{Executable: "sh", Args: []string{"-c", "exit 3"}},
+		{Executable: "sleep", Args: []string{"1"}, Timeout: 10 * time.Millisecond},
+		{Executable: "missing-executable"},
+	} {
+		_, err := recorder.Execute(context.Background(), c)
+		if err == nil {
+			t.Fatalf("expected error of %v", FormatCommand(c))
+		}
+	}
+
+	replay, err := NewReplayRunner(fixturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := replay.Execute(context.Background(), Command{Executable: "sh", Args: []string{"-c", "exit 3"}})
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || result.ExitCode != 3 || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("expected exit error with code 3, got %v, %v", result.ExitCode, err)
+	}
+	_, err = replay.Execute(context.Background(), Command{Executable: "sleep", Args: []string{"1"}})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected timeout, got %v", err)
+	}
+	_, err = replay.Execute(context.Background(), Command{Executable: "missing-executable"})
+	if err == nil || errors.As(err, &exitErr) || errors.Is(err, ErrTimeout) {
+		t.Errorf("expected plain error, got %v", err)
+	}
+}
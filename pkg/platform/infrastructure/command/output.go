@@ -0,0 +1,85 @@
+package command
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+)
+
+// tailBuffer keeps last limit bytes written to it, buffer grows up to twice the limit before it is trimmed,
+// so trimming is amortized over written bytes
+type tailBuffer struct {
+	mu    sync.Mutex
+	limit int
+	buf   []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	written := len(p)
+	if len(p) > b.limit {
+		p = p[len(p)-b.limit:]
+	}
+	if len(b.buf)+len(p) > 2*b.limit {
+		b.buf = append(b.buf[:0], b.tail()...)
+	}
+	b.buf = append(b.buf, p...)
+	return written, nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.tail())
+}
+
+func (b *tailBuffer) tail() []byte {
+	if len(b.buf) > b.limit {
+		return b.buf[len(b.buf)-b.limit:]
+	}
+	return b.buf
+}
+
+// lineWriter logs every complete line written to it
+type lineWriter struct {
+	mu      sync.Mutex
+	logger  applogger.Logger
+	prefix  string
+	pending []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pending = append(w.pending, p...)
+	for {
+		i := bytes.IndexByte(w.pending, '\n')
+		if i < 0 {
+			break
+		}
+		w.log(string(w.pending[:i]))
+		w.pending = w.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush logs incomplete last line
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.pending) > 0 {
+		w.log(string(w.pending))
+		w.pending = nil
+	}
+}
+
+func (w *lineWriter) log(line string) {
+	line = strings.TrimRight(line, "\r")
+	if w.prefix != "" {
+		line = "[" + w.prefix + "] " + line
+	}
+	w.logger.Info(line)
+}
@@ -0,0 +1,76 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"regexp"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+)
+
+var ErrTimeout = errors.New("command timed out")
+
+// transientOutput matches network errors of git and image registries which are safe to retry
+var transientOutput = regexp.MustCompile(`(?i)` +
+	`could not resolve host|connection (timed out|reset|refused)|operation timed out|i/o timeout|` +
+	`tls handshake timeout|early eof|unexpected eof|rpc failed|remote end hung up|` +
+	`temporary failure in name resolution|toomanyrequests|too many requests|` +
+	`(http|status|error|returned|code)[ :/0-9.]*\b50[234]\b|\b50[234] (bad gateway|service unavailable|gateway time-?out)`)
+
+// Policy is timeout and retries of command call site
+type Policy struct {
+	// Timeout limits every attempt, zero means no timeout
+	Timeout time.Duration
+	Retries int
+	// Backoff is delay before first retry, doubled for every next retry
+	Backoff time.Duration
+}
+
+// Override replaces policy fields set in platform config
+func (policy Policy) Override(override platform.CommandPolicy) Policy {
+	if override.Timeout != nil {
+		policy.Timeout = *override.Timeout
+	}
+	if override.Retries != nil {
+		policy.Retries = *override.Retries
+	}
+	if override.Backoff != nil {
+		policy.Backoff = *override.Backoff
+	}
+	return policy
+}
+
+// Apply sets policy to command
+func (policy Policy) Apply(command Command) Command {
+	command.Timeout = policy.Timeout
+	command.Retries = policy.Retries
+	command.Backoff = policy.Backoff
+	return command
+}
+
+// IsTransient reports whether failed command can be safely retried:
+// it timed out or exited with output of network error
+func IsTransient(result Result, err error) bool {
+	if errors.Is(err, ErrTimeout) {
+		return true
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return false
+	}
+	return transientOutput.MatchString(result.Stderr) || transientOutput.MatchString(result.Stdout)
+}
+
+// sleep waits for delay or context cancellation
+func sleep(ctx context.Context, delay time.Duration) error {
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+)
+
+func TestRunnerTerminatesProcessGroup(t *testing.T) {
+	r := NewCommandRunner(logger.NewTextLogger(), true, 100*time.Millisecond, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	// children ignore SIGTERM and must be killed after grace period
+	result, err := r.Execute(ctx, Command{
+		Executable: "sh",
+		Args:       []string{"-c", "trap '' TERM; sleep 30 & echo $!; wait"},
+	})
+	if !errors.Is(err, ErrInterrupted) {
+		t.Fatalf("expected interrupted error, got %v", err)
+	}
+	pid := strings.TrimSpace(result.Stdout)
+	if _, err = strconv.Atoi(pid); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	// killed grandchild can stay zombie until it is reaped by init
+	stat, err := os.ReadFile("/proc/" + pid + "/stat")
+	if err == nil && !strings.Contains(string(stat), ") Z ") {
+		t.Errorf("grandchild %v is still running", pid)
+	}
+}
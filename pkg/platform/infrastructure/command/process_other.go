@@ -0,0 +1,16 @@
+//go:build !unix
+
+package command
+
+import (
+	"os/exec"
+	"time"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+)
+
+// terminateProcessGroup kills only command process, process groups are supported only on unix
+func terminateProcessGroup(cmd *exec.Cmd, _ applogger.Logger, gracePeriod time.Duration) (stop func()) {
+	cmd.WaitDelay = gracePeriod
+	return func() {}
+}
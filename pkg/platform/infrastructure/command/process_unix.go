@@ -0,0 +1,51 @@
+//go:build unix
+
+package command
+
+import (
+	"fmt"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+)
+
+// terminateProcessGroup starts command in own process group, so cancellation reaches all its children:
+// group gets SIGTERM and SIGKILL after grace period. Returned stop must be called after Wait returned,
+// it cancels SIGKILL of exited group, so the signal does not reach reused process group id
+func terminateProcessGroup(cmd *exec.Cmd, logger applogger.Logger, gracePeriod time.Duration) (stop func()) {
+	var (
+		mu      sync.Mutex
+		stopped bool
+		pgid    int
+		kill    *time.Timer
+	)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		mu.Lock()
+		pgid = -cmd.Process.Pid
+		logger.Info(fmt.Sprintf("interrupt %v, terminate process group %v", cmd.String(), -pgid))
+		if !stopped {
+			kill = time.AfterFunc(gracePeriod, func() {
+				if syscall.Kill(pgid, syscall.SIGKILL) == nil {
+					logger.Info(fmt.Sprintf("killed process group %v after %v", -pgid, gracePeriod))
+				}
+			})
+		}
+		mu.Unlock()
+		return syscall.Kill(pgid, syscall.SIGTERM)
+	}
+	// output pipes can be held by children after group leader exited
+	cmd.WaitDelay = gracePeriod + time.Second
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		stopped = true
+		// children detached from output pipes can outlive leader, they still get SIGKILL
+		if kill != nil && syscall.Kill(pgid, 0) != nil {
+			kill.Stop()
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	LatestRun = "latest"
+	// platformLog contains commands not related to repository, such as pipelines
+	platformLog  = "platform"
+	defaultPhase = "commands"
+)
+
+// NewRunID returns sortable id of run
+func NewRunID() string {
+	return time.Now().UTC().Format("20060102-150405.000")
+}
+
+// NewRunLog creates log of run in <dir>/<run-id>, directory and latest symlink are created with first written command,
+// then only last retention runs are kept
+func NewRunLog(dir string, runID string, retention int) *RunLog {
+	return &RunLog{
+		dir:       dir,
+		runID:     runID,
+		retention: retention,
+		files:     make(map[string]*os.File),
+	}
+}
+
+// RunLog writes full output of commands to <dir>/<run-id>/<repository>/<phase>.log
+type RunLog struct {
+	dir       string
+	runID     string
+	retention int
+
+	mu    sync.Mutex
+	files map[string]*os.File
+}
+
+// Writer returns writer of command output log and its path
+func (log *RunLog) Writer(repository string, phase string) (io.Writer, string, error) {
+	if repository == "" {
+		repository = platformLog
+	}
+	if phase == "" {
+		phase = defaultPhase
+	}
+	logPath := path.Join(log.dir, log.runID, repository, phase+".log")
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if file, ok := log.files[logPath]; ok {
+		return file, logPath, nil
+	}
+	if len(log.files) == 0 {
+		err := log.linkLatest()
+		if err != nil {
+			return nil, "", err
+		}
+		err = PruneRuns(log.dir, log.retention)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	err := os.MkdirAll(path.Dir(logPath), 0o755)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to create log directory %v", path.Dir(logPath))
+	}
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, "", errors.Wrapf(err, "failed to open log %v", logPath)
+	}
+	log.files[logPath] = file
+	return file, logPath, nil
+}
+
+func (log *RunLog) Close() error {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	var err error
+	for logPath, file := range log.files {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = errors.Wrapf(closeErr, "failed to close log %v", logPath)
+		}
+	}
+	log.files = make(map[string]*os.File)
+	return err
+}
+
+func (log *RunLog) linkLatest() error {
+	err := os.MkdirAll(path.Join(log.dir, log.runID), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create run log directory %v", log.runID)
+	}
+	latest := path.Join(log.dir, LatestRun)
+	err = os.Remove(latest)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove %v", latest)
+	}
+	return errors.Wrapf(os.Symlink(log.runID, latest), "failed to link %v", latest)
+}
+
+// Runs returns ids of logged runs from oldest to newest
+func Runs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to read logs %v", dir)
+	}
+	var result []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			result = append(result, entry.Name())
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// PruneRuns removes oldest run logs except last keep runs, zero keep disables pruning
+func PruneRuns(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	runs, err := Runs(dir)
+	if err != nil {
+		return err
+	}
+	for len(runs) > keep {
+		err = os.RemoveAll(path.Join(dir, runs[0]))
+		if err != nil {
+			return errors.Wrapf(err, "failed to remove run log %v", runs[0])
+		}
+		runs = runs[1:]
+	}
+	return nil
+}
+
+// LogFiles returns log files of run, optionally only of one repository
+func LogFiles(dir string, runID string, repository string) ([]string, error) {
+	runDir := path.Join(dir, runID)
+	repositories, err := os.ReadDir(runDir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read run log %v", runID)
+	}
+	var result []string
+	for _, entry := range repositories {
+		if !entry.IsDir() || repository != "" && entry.Name() != repository {
+			continue
+		}
+		files, err2 := os.ReadDir(path.Join(runDir, entry.Name()))
+		if err2 != nil {
+			return nil, errors.Wrapf(err2, "failed to read logs of repository %v", entry.Name())
+		}
+		for _, file := range files {
+			if strings.HasSuffix(file.Name(), ".log") {
+				result = append(result, path.Join(runDir, entry.Name(), file.Name()))
+			}
+		}
+	}
+	if len(result) == 0 && repository != "" {
+		return nil, fmt.Errorf("no logs of repository %v in run %v", repository, runID)
+	}
+	return result, nil
+}
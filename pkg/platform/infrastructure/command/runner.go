@@ -1,66 +1,200 @@
 package command
 
 import (
-	"bufio"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"os"
 	"os/exec"
+	"strings"
+	"time"
 
 	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
 )
 
+const (
+	// maxCaptureSize limits captured stdout and stderr to their tails
+	maxCaptureSize = 1 << 20
+	// maxErrorOutputSize limits stderr tail added to command error
+	maxErrorOutputSize = 2048
+)
+
 type Command struct {
 	WorkDir    string
 	Executable string
 	Args       []string
-	Verbose    bool
+	// Env is added to environment inherited from current process and overrides inherited variables
+	Env map[string]string
+	// ClearEnv runs command only with Env, without variables of current process
+	ClearEnv bool
+	// Stdin is optional input of command, commands with stdin are not retried
+	Stdin io.Reader
+	// Verbose streams output lines to logger while command is running, when run log is enabled
+	// output is streamed only to run log
+	Verbose bool
+	// Console streams output of verbose command to logger even when run log is enabled
+	Console bool
+	// Repository is added to streamed output lines and selects directory of run log
+	Repository string
+	// Phase selects file of run log, such as fetch, sources or image
+	Phase string
+	// Timeout limits every attempt, zero means no timeout
+	Timeout time.Duration
+	// Retries is number of additional attempts after transient failure, see IsTransient
+	Retries int
+	// Backoff is delay before first retry, doubled for every next retry
+	Backoff time.Duration
+	// BeforeAttempt is optional cleanup called before every attempt, such as removal of partial result of failed attempt
+	BeforeAttempt func() error
+}
+
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	Duration time.Duration
 }
 
 type Runner interface {
-	Execute(ctx context.Context, command Command) (string, error)
+	// Execute runs command and captures its output, error contains tail of stderr
+	Execute(ctx context.Context, command Command) (Result, error)
 }
 
-func NewCommandRunner(logger applogger.Logger, silent bool) Runner {
+// ErrInterrupted is returned for commands stopped by cancellation of context
+var ErrInterrupted = errors.New("command interrupted")
+
+// NewCommandRunner creates runner, cancelled commands get gracePeriod to exit before they are killed,
+// runLog is optional log of full output of commands
+func NewCommandRunner(logger applogger.Logger, silent bool, gracePeriod time.Duration, runLog *RunLog) Runner {
 	return &runner{
-		logger: logger,
-		silent: silent,
+		logger:      logger,
+		silent:      silent,
+		gracePeriod: gracePeriod,
+		runLog:      runLog,
 	}
 }
 
 type runner struct {
-	logger applogger.Logger
-	silent bool
+	logger      applogger.Logger
+	silent      bool
+	gracePeriod time.Duration
+	runLog      *RunLog
 }
 
-func (r runner) Execute(ctx context.Context, command Command) (string, error) {
+func (r runner) Execute(ctx context.Context, command Command) (Result, error) {
 	if command.Executable == "" {
-		return "", errors.New("command executable can not be empty")
+		return Result{ExitCode: -1}, errors.New("command executable can not be empty")
+	}
+	backoff := command.Backoff
+	for attempt := 0; ; attempt++ {
+		if command.BeforeAttempt != nil {
+			err := command.BeforeAttempt()
+			if err != nil {
+				return Result{ExitCode: -1}, err
+			}
+		}
+		result, err := r.execute(ctx, command)
+		if err == nil || attempt >= command.Retries || command.Stdin != nil || ctx.Err() != nil || !IsTransient(result, err) {
+			return result, err
+		}
+		r.logger.Warning(err, fmt.Sprintf("attempt %v of %v failed, retry %v in %v",
+			attempt+1, command.Retries+1, command.Executable, backoff))
+		if err = sleep(ctx, backoff); err != nil {
+			return result, err
+		}
+		backoff *= 2
+	}
+}
+
+func (r runner) execute(ctx context.Context, command Command) (Result, error) {
+	if command.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, command.Timeout)
+		defer cancel()
 	}
 	// nolint:gosec
 	cmd := exec.CommandContext(ctx, command.Executable, command.Args...)
 	cmd.Dir = command.WorkDir
+	cmd.Env = commandEnv(command)
+	cmd.Stdin = command.Stdin
+	stopTermination := terminateProcessGroup(cmd, r.logger, r.gracePeriod)
 	r.logger.Debug(cmd.String())
-	if command.Verbose && !r.silent {
-		stdout, err := cmd.StdoutPipe()
-		if err != nil {
-			return "", err
-		}
-		go r.verboseLogger(stdout)
-		stderr, err := cmd.StderrPipe()
+
+	stdout := &tailBuffer{limit: maxCaptureSize}
+	stderr := &tailBuffer{limit: maxCaptureSize}
+	stdoutWriters, stderrWriters := []io.Writer{stdout}, []io.Writer{stderr}
+	var logPath string
+	if r.runLog != nil {
+		logWriter, p, err := r.runLog.Writer(command.Repository, command.Phase)
 		if err != nil {
-			return "", err
+			return Result{ExitCode: -1}, err
 		}
-		go r.verboseLogger(stderr)
-		return "", cmd.Run()
+		logPath = p
+		_, _ = fmt.Fprintf(logWriter, "$ %v\n", FormatCommand(command))
+		stdoutWriters = append(stdoutWriters, logWriter)
+		stderrWriters = append(stderrWriters, logWriter)
+	}
+	if command.Verbose && !r.silent && (r.runLog == nil || command.Console) {
+		stdoutLines := &lineWriter{logger: r.logger, prefix: command.Repository}
+		stderrLines := &lineWriter{logger: r.logger, prefix: command.Repository}
+		defer stdoutLines.Flush()
+		defer stderrLines.Flush()
+		stdoutWriters = append(stdoutWriters, stdoutLines)
+		stderrWriters = append(stderrWriters, stderrLines)
+	}
+	cmd.Stdout, cmd.Stderr = io.MultiWriter(stdoutWriters...), io.MultiWriter(stderrWriters...)
+
+	start := time.Now()
+	err := cmd.Run()
+	stopTermination()
+	result := Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: -1,
+		Duration: time.Since(start),
+	}
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	switch {
+	case err == nil:
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("%w after %v", ErrTimeout, command.Timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		err = fmt.Errorf("%w: %v", ErrInterrupted, err)
+	}
+	if err != nil && logPath != "" {
+		return result, fmt.Errorf("%w, full output in %v", commandError(cmd, err, result.Stderr), logPath)
 	}
-	result, err := cmd.CombinedOutput()
-	return string(result), err
+	if err != nil {
+		return result, commandError(cmd, err, result.Stderr)
+	}
+	return result, nil
 }
 
-func (r runner) verboseLogger(pipe io.Reader) {
-	scanner := bufio.NewScanner(pipe)
-	for scanner.Scan() {
-		r.logger.Info(scanner.Text())
+// commandEnv returns nil to inherit environment, later duplicates override earlier variables in exec.Cmd
+func commandEnv(command Command) []string {
+	if len(command.Env) == 0 && !command.ClearEnv {
+		return nil
+	}
+	result := make([]string, 0, len(command.Env))
+	if !command.ClearEnv {
+		result = append(result, os.Environ()...)
+	}
+	for key, value := range command.Env {
+		result = append(result, key+"="+value)
+	}
+	return result
+}
+
+func commandError(cmd *exec.Cmd, err error, stderr string) error {
+	stderr = strings.TrimSpace(stderr)
+	if stderr == "" {
+		return fmt.Errorf("%v: %w", cmd.String(), err)
+	}
+	if len(stderr) > maxErrorOutputSize {
+		stderr = "..." + stderr[len(stderr)-maxErrorOutputSize:]
 	}
+	return fmt.Errorf("%v: %w: %v", cmd.String(), err, stderr)
 }
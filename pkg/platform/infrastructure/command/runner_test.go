@@ -0,0 +1,143 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+)
+
+func TestRunnerCapturesOutput(t *testing.T) {
+	r := NewCommandRunner(logger.NewTextLogger(), false, time.Second, nil)
+
+	result, err := r.Execute(context.Background(), Command{
+		Executable: "sh",
+		Args:       []string{"-c", "echo out; echo err >&2; exit 3"},
+		Verbose:    true,
+		Repository: "repository",
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if !strings.Contains(err.Error(), "err") {
+		t.Errorf("expected stderr tail in error, got %v", err)
+	}
+	if result.Stdout != "out\n" || result.Stderr != "err\n" || result.ExitCode != 3 {
+		t.Errorf("unexpected result %+v", result)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	buffer := &tailBuffer{limit: 4}
+	_, _ = buffer.Write([]byte("abc"))
+	_, _ = buffer.Write([]byte("def"))
+	if buffer.String() != "cdef" {
+		t.Errorf("expected tail cdef, got %v", buffer.String())
+	}
+	for _, s := range []string{"g", "hi", "jklmnopq", "r"} {
+		_, _ = buffer.Write([]byte(s))
+	}
+	if buffer.String() != "opqr" || len(buffer.buf) > 2*buffer.limit {
+		t.Errorf("expected tail opqr in at most %v bytes, got %v in %v", 2*buffer.limit, buffer.String(), len(buffer.buf))
+	}
+}
+
+func TestRunnerRetriesOnlyTransientErrors(t *testing.T) {
+	r := NewCommandRunner(logger.NewTextLogger(), true, time.Second, nil)
+
+	for _, testCase := range []struct {
+		stderr   string
+		attempts int
+	}{
+		{stderr: "fatal: unable to access: Connection reset by peer", attempts: 3},
+		{stderr: "fatal: not a git repository", attempts: 1},
+	} {
+		attemptsFile := t.TempDir() + "/attempts"
+		_, err := r.Execute(context.Background(), Command{
+			Executable: "sh",
+			Args:       []string{"-c", "printf . >> " + attemptsFile + "; echo \"" + testCase.stderr + "\" >&2; exit 1"},
+			Retries:    2,
+			Backoff:    time.Millisecond,
+		})
+		if err == nil {
+			t.Fatal("expected error")
+		}
+		attempts, err := os.ReadFile(attemptsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(attempts) != testCase.attempts {
+			t.Errorf("expected %v attempts for %v, got %v", testCase.attempts, testCase.stderr, len(attempts))
+		}
+	}
+}
+
+func TestTransientOutput(t *testing.T) {
+	for output, transient := range map[string]bool{
+		"fatal: unable to access 'https://git/x/': The requested URL returned error: 502": true,
+		"received unexpected HTTP status: 503 Service Unavailable":                        true,
+		"< HTTP/1.1 504 Gateway Time-out":                                                 true,
+		"error parsing HTTP 403 response body":                                            false,
+		"src/index.ts:502:10 - error TS2304: Cannot find name":                            false,
+		"Receiving objects: 100% (503/503), 1.20 MiB":                                     false,
+	} {
+		if transientOutput.MatchString(output) != transient {
+			t.Errorf("expected transient %v for %q", transient, output)
+		}
+	}
+}
+
+func TestRunnerRemovesPartialResultBeforeAttempt(t *testing.T) {
+	r := NewCommandRunner(logger.NewTextLogger(), true, time.Second, nil)
+	partial := t.TempDir() + "/partial"
+	attempts := 0
+	_, err := r.Execute(context.Background(), Command{
+		Executable: "sh",
+		Args:       []string{"-c", "test ! -e " + partial + " && touch " + partial + " && echo 'i/o timeout' >&2; exit 1"},
+		Retries:    2,
+		Backoff:    time.Millisecond,
+		BeforeAttempt: func() error {
+			attempts++
+			return os.RemoveAll(partial)
+		},
+	})
+	if err == nil || attempts != 3 || !strings.Contains(err.Error(), "i/o timeout") {
+		t.Errorf("expected 3 attempts failed with transient error, got %v: %v", attempts, err)
+	}
+}
+
+func TestRunnerTimeout(t *testing.T) {
+	r := NewCommandRunner(logger.NewTextLogger(), true, time.Second, nil)
+
+	_, err := r.Execute(context.Background(), Command{
+		Executable: "sleep",
+		Args:       []string{"5"},
+		Timeout:    50 * time.Millisecond,
+	})
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+}
+
+func TestRunnerEnvAndStdin(t *testing.T) {
+	r := NewCommandRunner(logger.NewTextLogger(), true, time.Second, nil)
+	t.Setenv("PLATFORM_TEST_INHERITED", "inherited")
+
+	result, err := r.Execute(context.Background(), Command{
+		Executable: "/bin/sh",
+		Args:       []string{"-s"},
+		Env:        map[string]string{"PLATFORM_TEST_OWN": "own"},
+		ClearEnv:   true,
+		Stdin:      strings.NewReader("echo \"$PLATFORM_TEST_INHERITED|$PLATFORM_TEST_OWN\""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Stdout != "|own\n" {
+		t.Errorf("unexpected output %q", result.Stdout)
+	}
+}
@@ -1,8 +1,13 @@
 package buildconfig
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 
@@ -10,20 +15,62 @@ import (
 )
 
 type Command struct {
-	Executable string   `json:"executable"`
-	Args       []string `json:"args"`
+	Executable string            `json:"executable"`
+	Args       []string          `json:"args"`
+	WorkDir    string            `json:"workDir,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	Condition  string            `json:"condition,omitempty"`
+}
+
+// Commands can be declared as single command object or list of commands
+type Commands []Command
+
+func (commands *Commands) UnmarshalJSON(data []byte) error {
+	if len(bytes.TrimSpace(data)) > 0 && bytes.TrimSpace(data)[0] == '{' {
+		var command Command
+		err := json.Unmarshal(data, &command)
+		if err != nil {
+			return err
+		}
+		*commands = Commands{command}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]Command)(commands))
+}
+
+type Secret struct {
+	ID   string `json:"id"`
+	Env  string `json:"env,omitempty"`
+	File string `json:"file,omitempty"`
 }
 
 type Image struct {
-	Name       string `json:"name"`
-	Context    string `json:"context"`
-	DockerFile string `json:"dockerFile"`
-	SkipPush   bool   `json:"skipPush"`
+	Name       string            `json:"name"`
+	Context    string            `json:"context"`
+	DockerFile string            `json:"dockerFile"`
+	SkipPush   bool              `json:"skipPush"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Platforms  []string          `json:"platforms,omitempty"`
+	CacheFrom  []string          `json:"cacheFrom,omitempty"`
+	CacheTo    []string          `json:"cacheTo,omitempty"`
+	BuildArgs  map[string]string `json:"buildArgs,omitempty"`
+	Target     string            `json:"target,omitempty"`
+	Secrets    []Secret          `json:"secrets,omitempty"`
+}
+
+type Input struct {
+	Repository string `json:"repository"`
+	Output     string `json:"output"`
+	Path       string `json:"path"`
+	Mode       string `json:"mode,omitempty"`
 }
 
 type Build struct {
-	Sources Command `json:"sources"`
-	Images  []Image `json:"images"`
+	Sources Commands            `json:"sources"`
+	Images  []Image             `json:"images"`
+	Outputs []string            `json:"outputs,omitempty"`
+	Inputs  []Input             `json:"inputs,omitempty"`
+	Targets map[string]Commands `json:"targets,omitempty"`
 }
 
 type Config struct {
@@ -35,10 +82,13 @@ func NewLoader() *Loader {
 }
 
 type Loader struct {
+	mu    sync.Mutex
 	cache map[string]build.Config
 }
 
 func (l *Loader) Load(path string) (build.Config, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	config, ok := l.cache[path]
 	if ok {
 		return config, nil
@@ -61,24 +111,176 @@ func load(path string) (build.Config, error) {
 	if err != nil {
 		return build.Config{}, errors.Wrap(err, "failed to unmarshal config")
 	}
+	err = assertSecrets(infraConfig)
+	if err != nil {
+		return build.Config{}, errors.Wrapf(err, "invalid config %v", path)
+	}
+	err = assertInputs(infraConfig)
+	if err != nil {
+		return build.Config{}, errors.Wrapf(err, "invalid config %v", path)
+	}
+	err = assertOutputs(infraConfig)
+	if err != nil {
+		return build.Config{}, errors.Wrapf(err, "invalid config %v", path)
+	}
+	err = assertWorkDirs(infraConfig)
+	if err != nil {
+		return build.Config{}, errors.Wrapf(err, "invalid config %v", path)
+	}
 	return mapInfraConfigToAppConfig(infraConfig), nil
 }
 
+func assertSecrets(config Config) error {
+	for _, image := range config.Build.Images {
+		for _, secret := range image.Secrets {
+			if secret.ID == "" {
+				return fmt.Errorf("secret id for image %v is empty", image.Name)
+			}
+			if (secret.Env == "") == (secret.File == "") {
+				return fmt.Errorf("secret %v for image %v must reference either env or file", secret.ID, image.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func assertInputs(config Config) error {
+	for _, input := range config.Build.Inputs {
+		if input.Repository == "" || input.Output == "" || input.Path == "" {
+			return fmt.Errorf("input %v of repository %v must have repository, output and path", input.Output, input.Repository)
+		}
+		switch build.InputMode(input.Mode) {
+		case "", build.InputModeCopy, build.InputModeLink:
+		default:
+			return fmt.Errorf("unexpected mode %v of input %v", input.Mode, input.Output)
+		}
+		err := assertRepositoryPath(input.Output)
+		if err != nil {
+			return errors.Wrapf(err, "invalid output of input %v", input.Output)
+		}
+		err = assertRepositoryPath(input.Path)
+		if err != nil {
+			return errors.Wrapf(err, "invalid path of input %v", input.Output)
+		}
+	}
+	return nil
+}
+
+func assertOutputs(config Config) error {
+	for _, output := range config.Build.Outputs {
+		err := assertRepositoryPath(output)
+		if err != nil {
+			return errors.Wrap(err, "invalid output")
+		}
+	}
+	return nil
+}
+
+func assertWorkDirs(config Config) error {
+	err := assertCommandWorkDirs(config.Build.Sources)
+	if err != nil {
+		return errors.Wrap(err, "invalid sources")
+	}
+	for name, commands := range config.Build.Targets {
+		err = assertCommandWorkDirs(commands)
+		if err != nil {
+			return errors.Wrapf(err, "invalid target %v", name)
+		}
+	}
+	return nil
+}
+
+// assertCommandWorkDirs rejects work directories outside of repository, empty work directory is repository itself
+func assertCommandWorkDirs(commands Commands) error {
+	for _, command := range commands {
+		if command.WorkDir == "" || filepath.Clean(command.WorkDir) == "." {
+			continue
+		}
+		err := assertRepositoryPath(command.WorkDir)
+		if err != nil {
+			return errors.Wrapf(err, "invalid workDir of %v", command.Executable)
+		}
+	}
+	return nil
+}
+
+// assertRepositoryPath rejects paths outside of repository and repository itself,
+// inputs and outputs are removed before they are staged or collected
+func assertRepositoryPath(p string) error {
+	if p == "" {
+		return errors.New("path is empty")
+	}
+	if filepath.IsAbs(p) {
+		return fmt.Errorf("path %v must be relative to repository", p)
+	}
+	cleaned := filepath.Clean(p)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("path %v must be inside repository", p)
+	}
+	return nil
+}
+
 func mapInfraConfigToAppConfig(config Config) build.Config {
 	images := make([]build.Image, 0, len(config.Build.Images))
 	for _, image := range config.Build.Images {
+		secrets := make([]build.Secret, 0, len(image.Secrets))
+		for _, secret := range image.Secrets {
+			secrets = append(secrets, build.Secret{
+				ID:   secret.ID,
+				Env:  secret.Env,
+				File: secret.File,
+			})
+		}
 		images = append(images, build.Image{
 			Name:       image.Name,
 			Context:    image.Context,
 			DockerFile: image.DockerFile,
 			SkipPush:   image.SkipPush,
+			Labels:     image.Labels,
+			Platforms:  image.Platforms,
+			CacheFrom:  image.CacheFrom,
+			CacheTo:    image.CacheTo,
+			BuildArgs:  image.BuildArgs,
+			Target:     image.Target,
+			Secrets:    secrets,
+		})
+	}
+	inputs := make([]build.Input, 0, len(config.Build.Inputs))
+	for _, input := range config.Build.Inputs {
+		mode := build.InputMode(input.Mode)
+		if mode == "" {
+			mode = build.InputModeCopy
+		}
+		inputs = append(inputs, build.Input{
+			Repository: input.Repository,
+			Output:     input.Output,
+			Path:       input.Path,
+			Mode:       mode,
 		})
 	}
+	targets := make(map[string][]build.Command, len(config.Build.Targets))
+	for name, commands := range config.Build.Targets {
+		targets[name] = mapCommands(commands)
+	}
 	return build.Config{
-		Sources: build.Command{
-			Executable: config.Build.Sources.Executable,
-			Args:       config.Build.Sources.Args,
-		},
-		Images: images,
+		Sources: mapCommands(config.Build.Sources),
+		Images:  images,
+		Outputs: config.Build.Outputs,
+		Inputs:  inputs,
+		Targets: targets,
+	}
+}
+
+func mapCommands(commands Commands) []build.Command {
+	result := make([]build.Command, 0, len(commands))
+	for _, command := range commands {
+		result = append(result, build.Command{
+			Executable: command.Executable,
+			Args:       command.Args,
+			WorkDir:    command.WorkDir,
+			Env:        command.Env,
+			Condition:  command.Condition,
+		})
 	}
+	return result
 }
@@ -0,0 +1,47 @@
+package buildconfig
+
+import "testing"
+
+func TestAssertRepositoryPath(t *testing.T) {
+	for p, valid := range map[string]bool{
+		"":              false,
+		".":             false,
+		"./":            false,
+		"..":            false,
+		"../x":          false,
+		"a/../../x":     false,
+		"/tmp/x":        false,
+		"dist":          true,
+		"./dist":        true,
+		"a/../dist":     true,
+		"..dist":        true,
+		"vendor/shared": true,
+	} {
+		err := assertRepositoryPath(p)
+		if (err == nil) != valid {
+			t.Errorf("unexpected result for %q: %v", p, err)
+		}
+	}
+}
+
+func TestAssertWorkDirs(t *testing.T) {
+	for workDir, valid := range map[string]bool{
+		"":      true,
+		".":     true,
+		"web":   true,
+		"./web": true,
+		"..":    false,
+		"../..": false,
+		"/tmp":  false,
+	} {
+		for name, config := range map[string]Config{
+			"sources": {Build: Build{Sources: Commands{{Executable: "make", WorkDir: workDir}}}},
+			"target":  {Build: Build{Targets: map[string]Commands{"test": {{Executable: "make", WorkDir: workDir}}}}},
+		} {
+			err := assertWorkDirs(config)
+			if (err == nil) != valid {
+				t.Errorf("unexpected result for %v workDir %q: %v", name, workDir, err)
+			}
+		}
+	}
+}
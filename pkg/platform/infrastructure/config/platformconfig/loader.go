@@ -4,27 +4,75 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
 )
 
+const (
+	defaultSourceCacheDir     = ".platform/cache/sources"
+	defaultSourceCacheMaxSize = 10 << 30
+	defaultGracePeriod        = 10 * time.Second
+	defaultRunLogRetention    = 20
+)
+
 type Context struct {
 	BaseContext string            `json:"baseContext,omitempty"`
 	Branches    map[string]string `json:"branches"`
+	BranchTag   string            `json:"branchTag,omitempty"`
 }
 
 type Repository struct {
 	GitSrc    string   `json:"gitSrc"`
 	DependsOn []string `json:"dependsOn"`
 	Images    []string `json:"images"`
+	// Env values support ${VAR} references to environment of platform process
+	Env map[string]string `json:"env,omitempty"`
+}
+
+type BuildCache struct {
+	CacheFrom []string `json:"cacheFrom,omitempty"`
+	CacheTo   []string `json:"cacheTo,omitempty"`
+}
+
+type BuildArgNames struct {
+	Hash  string `json:"hash,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+type SourceCache struct {
+	Dir string `json:"dir,omitempty"`
+	// MaxSize supports suffixes: KB, MB, GB, KiB, MiB, GiB
+	MaxSize string `json:"maxSize,omitempty"`
+}
+
+type CommandPolicy struct {
+	// Timeout and Backoff are durations like 30s or 5m
+	Timeout string `json:"timeout,omitempty"`
+	Retries *int   `json:"retries,omitempty"`
+	Backoff string `json:"backoff,omitempty"`
 }
 
 type Config struct {
-	RepoSrc      string                `json:"repoSrc"`
-	Registry     string                `json:"registry"`
-	Contexts     map[string]Context    `json:"contexts"`
-	Repositories map[string]Repository `json:"repositories"`
-	Pipelines    map[string]string     `json:"pipelines"`
+	RepoSrc       string                `json:"repoSrc"`
+	Registry      string                `json:"registry"`
+	Contexts      map[string]Context    `json:"contexts"`
+	Repositories  map[string]Repository `json:"repositories"`
+	Pipelines     map[string]string     `json:"pipelines"`
+	ImageTags     []string              `json:"imageTags,omitempty"`
+	ImageBuilder  string                `json:"imageBuilder,omitempty"`
+	BuildCache    BuildCache            `json:"buildCache"`
+	BuildArgNames BuildArgNames         `json:"buildArgNames"`
+	SourceCache   SourceCache           `json:"sourceCache"`
+	// Commands overrides policies of clone, fetch, push and imagePush commands
+	Commands map[string]CommandPolicy `json:"commands,omitempty"`
+	// GracePeriod is duration like 10s
+	GracePeriod string `json:"gracePeriod,omitempty"`
+	// RunLogRetention is number of kept run logs
+	RunLogRetention *int `json:"runLogRetention,omitempty"`
 }
 
 func Load(path string) (platform.Platform, error) {
@@ -43,28 +91,72 @@ func Load(path string) (platform.Platform, error) {
 		return platform.Platform{}, err
 	}
 
-	for contextID, context := range config.Contexts {
+	config.Contexts, err = resolveContexts(config.Contexts)
+	if err != nil {
+		return platform.Platform{}, err
+	}
+
+	return mapToPlatformConfig(config)
+}
+
+// resolveContexts merges branches and branch tag of base contexts into contexts,
+// base context is resolved before contexts based on it, so values are inherited through all levels
+func resolveContexts(contexts map[string]Context) (map[string]Context, error) {
+	resolved := make(map[string]Context, len(contexts))
+	resolving := make(map[string]bool)
+	var resolve func(contextID string) (Context, error)
+	resolve = func(contextID string) (Context, error) {
+		if context, ok := resolved[contextID]; ok {
+			return context, nil
+		}
+		if resolving[contextID] {
+			return Context{}, fmt.Errorf("cycle of base contexts at context %v", contextID)
+		}
+		resolving[contextID] = true
+		context := contexts[contextID]
 		if context.BaseContext != "" {
-			baseContext, ok := config.Contexts[context.BaseContext]
-			if !ok {
-				return platform.Platform{}, fmt.Errorf(
-					"base context %v for context %v not found", context.BaseContext, contextID,
-				)
+			if _, ok := contexts[context.BaseContext]; !ok {
+				return Context{}, fmt.Errorf("base context %v for context %v not found", context.BaseContext, contextID)
+			}
+			baseContext, err := resolve(context.BaseContext)
+			if err != nil {
+				return Context{}, err
 			}
 			context = mergeContextBranches(baseContext, context)
+			if context.BranchTag == "" {
+				context.BranchTag = baseContext.BranchTag
+			}
+		}
+		err := assertBranchTag(contextID, context.BranchTag)
+		if err != nil {
+			return Context{}, err
 		}
+		resolved[contextID] = context
+		return context, nil
 	}
 
-	return mapToPlatformConfig(config), nil
+	contextIDs := make([]string, 0, len(contexts))
+	for contextID := range contexts {
+		contextIDs = append(contextIDs, contextID)
+	}
+	sort.Strings(contextIDs)
+	for _, contextID := range contextIDs {
+		_, err := resolve(contextID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resolved, nil
 }
 
-func mapToPlatformConfig(config Config) platform.Platform {
+func mapToPlatformConfig(config Config) (platform.Platform, error) {
 	contexts := make(map[platform.ContextID]platform.Context)
 	for contextID, context := range config.Contexts {
 		contexts[contextID] = platform.Context{
 			ID:            contextID,
 			BaseContextID: toOptString(context.BaseContext),
 			Branches:      context.Branches,
+			BranchTag:     toBranchTagPolicy(context.BranchTag),
 		}
 	}
 
@@ -75,24 +167,163 @@ func mapToPlatformConfig(config Config) platform.Platform {
 			GitSrc:    repository.GitSrc,
 			Images:    repository.Images,
 			DependsOn: repository.DependsOn,
+			Env:       expandEnv(repository.Env),
 		})
 	}
 
+	sourceCache, err := mapSourceCache(config.SourceCache)
+	if err != nil {
+		return platform.Platform{}, err
+	}
+	commands, err := mapCommandPolicies(config.Commands)
+	if err != nil {
+		return platform.Platform{}, err
+	}
+	gracePeriod, err := parseOptDuration(config.GracePeriod)
+	if err != nil {
+		return platform.Platform{}, fmt.Errorf("invalid grace period: %w", err)
+	}
+	if gracePeriod == nil {
+		defaultValue := defaultGracePeriod
+		gracePeriod = &defaultValue
+	}
+	runLogRetention := defaultRunLogRetention
+	if config.RunLogRetention != nil {
+		runLogRetention = *config.RunLogRetention
+	}
+	if runLogRetention < 1 {
+		return platform.Platform{}, fmt.Errorf("run log retention %v must be at least 1", runLogRetention)
+	}
+
 	return platform.Platform{
 		RepoSrc:      config.RepoSrc,
 		Registry:     config.Registry,
 		Contexts:     contexts,
 		Repositories: repositories,
 		Pipelines:    config.Pipelines,
+		ImageTags:    config.ImageTags,
+		ImageBuilder: config.ImageBuilder,
+		BuildCache: platform.BuildCache{
+			CacheFrom: config.BuildCache.CacheFrom,
+			CacheTo:   config.BuildCache.CacheTo,
+		},
+		BuildArgNames: platform.BuildArgNames{
+			Hash:  config.BuildArgNames.Hash,
+			Image: config.BuildArgNames.Image,
+		},
+		SourceCache:     sourceCache,
+		Commands:        commands,
+		GracePeriod:     *gracePeriod,
+		RunLogRetention: runLogRetention,
+	}, nil
+}
+
+func mapCommandPolicies(config map[string]CommandPolicy) (map[string]platform.CommandPolicy, error) {
+	result := make(map[string]platform.CommandPolicy, len(config))
+	for name, policy := range config {
+		switch name {
+		case platform.CommandClone, platform.CommandFetch, platform.CommandPush, platform.CommandImagePush:
+		default:
+			return nil, fmt.Errorf("unknown command %v in commands", name)
+		}
+		if policy.Retries != nil && *policy.Retries < 0 {
+			return nil, fmt.Errorf("negative retries for command %v", name)
+		}
+		timeout, err := parseOptDuration(policy.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout for command %v: %w", name, err)
+		}
+		backoff, err := parseOptDuration(policy.Backoff)
+		if err != nil {
+			return nil, fmt.Errorf("invalid backoff for command %v: %w", name, err)
+		}
+		result[name] = platform.CommandPolicy{
+			Timeout: timeout,
+			Retries: policy.Retries,
+			Backoff: backoff,
+		}
+	}
+	return result, nil
+}
+
+func parseOptDuration(v string) (*time.Duration, error) {
+	if v == "" {
+		return nil, nil
+	}
+	duration, err := time.ParseDuration(v)
+	if err != nil {
+		return nil, err
+	}
+	if duration < 0 {
+		return nil, fmt.Errorf("negative duration %v", v)
+	}
+	return &duration, nil
+}
+
+func mapSourceCache(config SourceCache) (platform.SourceCache, error) {
+	result := platform.SourceCache{
+		Dir:     config.Dir,
+		MaxSize: defaultSourceCacheMaxSize,
+	}
+	if result.Dir == "" {
+		result.Dir = defaultSourceCacheDir
+	}
+	if config.MaxSize != "" {
+		maxSize, err := parseSize(config.MaxSize)
+		if err != nil {
+			return platform.SourceCache{}, err
+		}
+		result.MaxSize = maxSize
 	}
+	return result, nil
+}
+
+func parseSize(size string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"KiB", 1 << 10},
+		{"MiB", 1 << 20},
+		{"GiB", 1 << 30},
+		{"KB", 1e3},
+		{"MB", 1e6},
+		{"GB", 1e9},
+		{"B", 1},
+	}
+	size = strings.TrimSpace(size)
+	multiplier := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(size, unit.suffix) {
+			size = strings.TrimSpace(strings.TrimSuffix(size, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+	value, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %v", size)
+	}
+	return value * multiplier, nil
+}
+
+func expandEnv(env map[string]string) map[string]string {
+	result := make(map[string]string, len(env))
+	for key, value := range env {
+		result[key] = os.ExpandEnv(value)
+	}
+	return result
 }
 
 func mergeContextBranches(baseContext, context Context) Context {
+	branches := make(map[string]string, len(baseContext.Branches)+len(context.Branches))
 	for repositoryID, branch := range baseContext.Branches {
-		if _, ok := context.Branches[repositoryID]; !ok {
-			context.Branches[repositoryID] = branch
-		}
+		branches[repositoryID] = branch
 	}
+	for repositoryID, branch := range context.Branches {
+		branches[repositoryID] = branch
+	}
+	context.Branches = branches
 	return context
 }
 
@@ -107,6 +338,22 @@ func assertRepositories(config Config) error {
 	return nil
 }
 
+func assertBranchTag(contextID string, branchTag string) error {
+	switch platform.BranchTagPolicy(branchTag) {
+	case "", platform.BranchTagStrict, platform.BranchTagOwnBranch, platform.BranchTagContextName:
+		return nil
+	default:
+		return fmt.Errorf("unexpected branch tag policy %v for context %v", branchTag, contextID)
+	}
+}
+
+func toBranchTagPolicy(v string) platform.BranchTagPolicy {
+	if v == "" {
+		return platform.BranchTagStrict
+	}
+	return platform.BranchTagPolicy(v)
+}
+
 func toOptString(v string) *string {
 	if v == "" {
 		return nil
@@ -0,0 +1,49 @@
+package platformconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolveContexts(t *testing.T) {
+	contexts, err := resolveContexts(map[string]Context{
+		"feature": {BaseContext: "release", Branches: map[string]string{"a": "feature"}},
+		"release": {BaseContext: "master", Branches: map[string]string{"b": "release"}},
+		"master":  {Branches: map[string]string{"a": "master", "b": "master", "c": "master"}, BranchTag: "context-name"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	feature := contexts["feature"]
+	if expected := map[string]string{"a": "feature", "b": "release", "c": "master"}; !reflect.DeepEqual(feature.Branches, expected) {
+		t.Errorf("expected branches %v, got %v", expected, feature.Branches)
+	}
+	if feature.BranchTag != "context-name" {
+		t.Errorf("expected branch tag of nested base context, got %q", feature.BranchTag)
+	}
+	if release := contexts["release"]; release.BranchTag != "context-name" || release.Branches["a"] != "master" {
+		t.Errorf("unexpected release context %+v", release)
+	}
+
+	for name, invalid := range map[string]map[string]Context{
+		"cycle": {
+			"a": {BaseContext: "b"},
+			"b": {BaseContext: "a"},
+		},
+		"self": {
+			"a": {BaseContext: "a"},
+		},
+		"missing base": {
+			"a": {BaseContext: "b"},
+		},
+		"invalid branch tag": {
+			"a": {BranchTag: "unknown"},
+			"b": {BaseContext: "a"},
+		},
+	} {
+		_, err = resolveContexts(invalid)
+		if err == nil {
+			t.Errorf("expected error for %v", name)
+		}
+	}
+}
@@ -10,8 +10,12 @@ import (
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/builder"
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/config/buildconfig"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/event"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/history"
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/pipeline"
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/provider"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/report"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/shell"
 )
 
 var dependencyContainer = struct{}{}
@@ -19,28 +23,166 @@ var dependencyContainer = struct{}{}
 type Container interface {
 	Platform() service.Platform
 	RepositoryProvider() service.RepositoryProvider
+	ReportRecorder() *report.Recorder
+	Events() *event.Stream
+	Timings() *service.TimingTree
+	History() *history.Recorder
+	// Close flushes logs of run
+	Close() error
+}
+
+type Options struct {
+	// SilentMode disables streaming of command output
+	SilentMode bool
+	// DryRun prints commands changing repositories or images instead of running them
+	DryRun bool
+	// RecordFixture writes executed commands and their results to fixture file
+	RecordFixture string
+	// ReplayFixture serves command results from fixture file instead of running commands
+	ReplayFixture string
+	// LogEvents logs events on info level, it is used with json log format
+	LogEvents bool
+	// RunID identifies run in run logs and history
+	RunID string
+	// RunLogDir enables logs of full command output in <RunLogDir>/<run-id>/<repository>/<phase>.log
+	RunLogDir string
 }
 
 func NewDependencyContainer(
 	logger applogger.Logger,
 	platformConfig platform.Platform,
-	silentMode bool,
-) Container {
-	runner := command.NewCommandRunner(logger, silentMode)
-	repositoryProvider := provider.NewRepositoryProvider(platformConfig.RepoSrc, runner)
-	repositoryBuilder := builder.NewRepositoryBuilder(logger, buildconfig.NewLoader(), repositoryProvider, runner)
-	pipelineExecutor := pipeline.NewPipelineExecutor(platformConfig.Registry, platformConfig.Pipelines, runner, repositoryProvider)
-	platformService := service.NewPlatformService(platformConfig, logger, repositoryProvider, repositoryBuilder, pipelineExecutor)
+	options Options,
+) (Container, error) {
+	var runLog *command.RunLog
+	if options.RunLogDir != "" {
+		runLog = command.NewRunLog(options.RunLogDir, options.RunID, platformConfig.RunLogRetention)
+	}
+	runner, err := newRunner(logger, platformConfig, options, runLog)
+	if err != nil {
+		return nil, err
+	}
+	replay, _ := runner.(*command.ReplayRunner)
+	if options.DryRun {
+		runner = command.NewDryRunRunner(logger, runner)
+	}
+	recorder := report.NewRecorder()
+	events := event.NewStream(logger, options.LogEvents)
+	events.Subscribe(recorder.Handle)
+	timings := service.NewTimingTree(platformConfig, logger)
+	events.Subscribe(timings.Handle)
+	historyRecorder := history.NewRecorder()
+	events.Subscribe(historyRecorder.Handle)
+	repositoryProvider := provider.NewRepositoryProvider(
+		platformConfig.RepoSrc,
+		runner,
+		platformConfig.Commands,
+		platformConfig.Repositories,
+	)
+	imageBuilder, err := builder.NewImageBuilder(
+		platformConfig.ImageBuilder,
+		logger,
+		runner,
+		platformConfig.Commands[platform.CommandImagePush],
+	)
+	if err != nil {
+		return nil, err
+	}
+	sourceCache := builder.NewSourceCache(logger, platformConfig.SourceCache.Dir, platformConfig.SourceCache.MaxSize)
+	repositoryBuilder := builder.NewRepositoryBuilder(
+		logger,
+		buildconfig.NewLoader(),
+		repositoryProvider,
+		runner,
+		imageBuilder,
+		sourceCache,
+		platformConfig.ImageTags,
+		platformConfig.BuildCache,
+		platformConfig.BuildArgNames,
+		events,
+		options.DryRun,
+	)
+	pipelineExecutor := pipeline.NewPipelineExecutor(
+		platformConfig.Registry,
+		platformConfig.Pipelines,
+		runner,
+		repositoryProvider,
+		events,
+	)
+	platformService := service.NewPlatformService(
+		platformConfig,
+		logger,
+		repositoryProvider,
+		repositoryBuilder,
+		pipelineExecutor,
+		sourceCache,
+		shell.NewCommandExecutor(runner, repositoryProvider),
+		events,
+	)
 
 	return &container{
 		platform:           platformService,
 		repositoryProvider: repositoryProvider,
+		reportRecorder:     recorder,
+		events:             events,
+		timings:            timings,
+		history:            historyRecorder,
+		runLog:             runLog,
+		replay:             replay,
+	}, nil
+}
+
+func newRunner(
+	logger applogger.Logger,
+	platformConfig platform.Platform,
+	options Options,
+	runLog *command.RunLog,
+) (command.Runner, error) {
+	if options.ReplayFixture != "" && options.RecordFixture != "" {
+		return nil, errors.New("record and replay of commands can not be used together")
+	}
+	if options.ReplayFixture != "" {
+		return command.NewReplayRunner(options.ReplayFixture)
+	}
+	runner := command.NewCommandRunner(logger, options.SilentMode, platformConfig.GracePeriod, runLog)
+	if options.RecordFixture != "" {
+		runner = command.NewRecordingRunner(runner, options.RecordFixture)
 	}
+	return runner, nil
 }
 
 type container struct {
 	platform           service.Platform
 	repositoryProvider service.RepositoryProvider
+	reportRecorder     *report.Recorder
+	events             *event.Stream
+	timings            *service.TimingTree
+	history            *history.Recorder
+	runLog             *command.RunLog
+	// replay is set when command results are served from fixture
+	replay *command.ReplayRunner
+}
+
+func (c *container) Close() error {
+	if c.runLog == nil {
+		return nil
+	}
+	return c.runLog.Close()
+}
+
+func (c *container) History() *history.Recorder {
+	return c.history
+}
+
+func (c *container) Timings() *service.TimingTree {
+	return c.timings
+}
+
+func (c *container) Events() *event.Stream {
+	return c.events
+}
+
+func (c *container) ReportRecorder() *report.Recorder {
+	return c.reportRecorder
 }
 
 func (c *container) RepositoryProvider() service.RepositoryProvider {
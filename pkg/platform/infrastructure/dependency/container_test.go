@@ -0,0 +1,148 @@
+package dependency
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/tss-calculator/go-lib/pkg/infrastructure/logger"
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+var testConfig = platform.Platform{
+	RepoSrc: "testdata/src",
+	Contexts: map[platform.ContextID]platform.Context{
+		"default": {ID: "default", Branches: map[platform.RepositoryID]string{"a": "master", "b": "feature"}},
+		"master":  {ID: "master", Branches: map[platform.RepositoryID]string{"a": "master", "b": "master"}},
+	},
+	Repositories: []platform.Repository{
+		{ID: "a", GitSrc: "git@example.com:a.git"},
+		{ID: "b", GitSrc: "git@example.com:b.git", DependsOn: []platform.RepositoryID{"a"}},
+	},
+}
+
+var testBuildConfig = platform.Platform{
+	RepoSrc: "testdata/build",
+	Contexts: map[platform.ContextID]platform.Context{
+		"default": {ID: "default", Branches: map[platform.RepositoryID]string{"a": "master", "b": "master"}},
+	},
+	Repositories: []platform.Repository{
+		{ID: "b", GitSrc: "git@example.com:b.git", DependsOn: []platform.RepositoryID{"a"}},
+		{ID: "a", GitSrc: "git@example.com:a.git"},
+	},
+}
+
+func newReplayContainer(t *testing.T, config platform.Platform, fixture string) (Container, *command.ReplayRunner) {
+	t.Helper()
+	config.SourceCache.Dir = t.TempDir()
+	c, err := NewDependencyContainer(logger.NewTextLogger(), config, Options{SilentMode: true, ReplayFixture: fixture})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, c.(*container).replay
+}
+
+// chdirCopy copies directory to temporary working directory of test, so build writes artifacts and manifest there
+func chdirCopy(t *testing.T, dir string) {
+	t.Helper()
+	workDir := t.TempDir()
+	err := filepath.WalkDir(dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		destination := filepath.Join(workDir, p)
+		if entry.IsDir() {
+			return os.MkdirAll(destination, 0o755)
+		}
+		body, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(destination, body, 0o644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(previous)
+	})
+}
+
+func TestCheckoutReplay(t *testing.T) {
+	c, replay := newReplayContainer(t, testConfig, "testdata/checkout.json")
+
+	err := c.Platform().Checkout(context.Background(), "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unused := replay.Unused(); len(unused) > 0 {
+		t.Errorf("expected all recorded commands to run, unused %+v", unused)
+	}
+}
+
+func TestMergeContextRollbackReplay(t *testing.T) {
+	c, replay := newReplayContainer(t, testConfig, "testdata/merge-rollback.json")
+
+	err := c.Platform().MergeContext(context.Background(), "default")
+	if err == nil {
+		t.Fatal("expected merge error")
+	}
+	if unused := replay.Unused(); len(unused) > 0 {
+		t.Errorf("expected repository to be reset after failed merge, unused %+v", unused)
+	}
+}
+
+func TestBuildOrderReplay(t *testing.T) {
+	fixture, err := filepath.Abs("testdata/build-order.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chdirCopy(t, "testdata/build")
+	c, replay := newReplayContainer(t, testBuildConfig, fixture)
+	var sources []platform.RepositoryID
+	c.Events().Subscribe(func(event service.Event) {
+		if event.Type == service.EventStepFinished && event.Phase == service.PhaseSources {
+			sources = append(sources, event.RepositoryID)
+		}
+	})
+
+	err = c.Platform().Build(context.Background(), "default", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unused := replay.Unused(); len(unused) > 0 {
+		t.Errorf("expected all recorded commands to run, unused %+v", unused)
+	}
+	if expected := []platform.RepositoryID{"a", "b"}; !reflect.DeepEqual(sources, expected) {
+		t.Errorf("expected dependency to be built first %v, got %v", expected, sources)
+	}
+	if _, err = os.Stat("testdata/build/b/vendor/a/a.txt"); err != nil {
+		t.Errorf("expected output of dependency staged as input: %v", err)
+	}
+	if _, err = os.Stat(".platform/build-manifest.json"); err != nil {
+		t.Errorf("expected build manifest: %v", err)
+	}
+}
+
+func TestRecordWithReplay(t *testing.T) {
+	_, err := NewDependencyContainer(logger.NewTextLogger(), testConfig, Options{
+		RecordFixture: t.TempDir() + "/fixture.json",
+		ReplayFixture: "testdata/checkout.json",
+	})
+	if err == nil {
+		t.Error("expected error of record with replay")
+	}
+}
@@ -0,0 +1,78 @@
+package event
+
+import (
+	"sync"
+
+	applogger "github.com/tss-calculator/go-lib/pkg/application/logger"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+type Handler func(event service.Event)
+
+// NewStream creates publisher which passes events to subscribers and logs them as structured entries,
+// logInfo logs events on info level instead of debug level, it is used with json log format
+func NewStream(logger applogger.Logger, logInfo bool) *Stream {
+	return &Stream{
+		logger:  logger,
+		logInfo: logInfo,
+	}
+}
+
+type Stream struct {
+	logger  applogger.Logger
+	logInfo bool
+
+	mu       sync.Mutex
+	handlers []Handler
+}
+
+func (stream *Stream) Subscribe(handler Handler) {
+	stream.mu.Lock()
+	defer stream.mu.Unlock()
+	stream.handlers = append(stream.handlers, handler)
+}
+
+func (stream *Stream) Publish(event service.Event) {
+	stream.log(event)
+
+	stream.mu.Lock()
+	handlers := make([]Handler, len(stream.handlers))
+	copy(handlers, stream.handlers)
+	stream.mu.Unlock()
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
+
+func (stream *Stream) log(event service.Event) {
+	fields := applogger.Fields{
+		"event": string(event.Type),
+	}
+	for key, value := range map[string]string{
+		"repository": event.RepositoryID,
+		"name":       event.Name,
+		"image":      event.Image,
+		"digest":     event.Digest,
+		"commit":     event.Commit,
+		"hash":       event.Hash,
+	} {
+		if value != "" {
+			fields[key] = value
+		}
+	}
+	if event.Duration > 0 {
+		fields["duration_seconds"] = event.Duration.Seconds()
+	}
+	if event.Skipped {
+		fields["skipped"] = true
+	}
+	if event.Err != nil {
+		fields["error"] = event.Err.Error()
+	}
+	logger := stream.logger.WithFields(fields)
+	if stream.logInfo {
+		logger.Info(string(event.Type))
+		return
+	}
+	logger.Debug(string(event.Type))
+}
@@ -0,0 +1,260 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const (
+	StatusSuccess     = "success"
+	StatusFailed      = "failed"
+	StatusInterrupted = "interrupted"
+
+	// Latest selects last recorded run, which is not dry-run
+	Latest = "latest"
+)
+
+// Entry is one invocation of platform command, entries are appended to history file as json lines
+type Entry struct {
+	ID           string                `json:"id"`
+	Command      string                `json:"command"`
+	Args         []string              `json:"args,omitempty"`
+	Context      string                `json:"context"`
+	User         string                `json:"user,omitempty"`
+	Start        time.Time             `json:"start"`
+	End          time.Time             `json:"end"`
+	Status       string                `json:"status"`
+	DryRun       bool                  `json:"dryRun,omitempty"`
+	Error        string                `json:"error,omitempty"`
+	Repositories map[string]Repository `json:"repositories,omitempty"`
+	Pushed       []Image               `json:"pushed,omitempty"`
+}
+
+// Repository is state of repository resolved by command or left by command changing repositories
+type Repository struct {
+	// Branch is checked out branch, HEAD for detached checkout
+	Branch string `json:"branch,omitempty"`
+	Commit string `json:"commit"`
+	Hash   string `json:"hash"`
+}
+
+type Image struct {
+	Repository string `json:"repository"`
+	Reference  string `json:"reference"`
+	Digest     string `json:"digest,omitempty"`
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{repositories: make(map[string]Repository)}
+}
+
+// Recorder collects repository states and pushed images of current run
+type Recorder struct {
+	mu           sync.Mutex
+	repositories map[string]Repository
+	pushed       []Image
+}
+
+// Handle records resolved repositories and pushed images, recorder subscribes it to event stream
+func (recorder *Recorder) Handle(event service.Event) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	switch event.Type {
+	case service.EventRepositoryResolved:
+		recorder.repositories[event.RepositoryID] = Repository{
+			Branch: event.Name,
+			Commit: event.Commit,
+			Hash:   event.Hash,
+		}
+	case service.EventImagePushed:
+		recorder.pushed = append(recorder.pushed, Image{
+			Repository: event.RepositoryID,
+			Reference:  event.Image,
+			Digest:     event.Digest,
+		})
+	}
+}
+
+// Complete fills entry with recorded repositories and images
+func (recorder *Recorder) Complete(entry Entry) Entry {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	if len(recorder.repositories) > 0 {
+		entry.Repositories = make(map[string]Repository, len(recorder.repositories))
+		for id, repository := range recorder.repositories {
+			entry.Repositories[id] = repository
+		}
+	}
+	entry.Pushed = append([]Image(nil), recorder.pushed...)
+	return entry
+}
+
+// Append writes entry as last line of history file, file and its directory are created when missing
+func Append(file string, entry Entry) error {
+	err := os.MkdirAll(path.Dir(file), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create history directory of %v", file)
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode history entry")
+	}
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open history %v", file)
+	}
+	_, err = f.Write(append(data, '\n'))
+	if err2 := f.Close(); err == nil {
+		err = err2
+	}
+	return errors.Wrapf(err, "failed to append history %v", file)
+}
+
+// Load reads entries of history file in order of runs, missing file is empty history
+func Load(file string) ([]Entry, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open history %v", file)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode history %v line %v", file, line)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, errors.Wrapf(scanner.Err(), "failed to read history %v", file)
+}
+
+// Filter selects entries, empty fields match any entry
+type Filter struct {
+	Command    string
+	Context    string
+	Status     string
+	Repository string
+	Branch     string
+	// IncludeDryRun selects also dry-run entries, they have canned digests of pushed images
+	IncludeDryRun bool
+}
+
+func (filter Filter) Match(entry Entry) bool {
+	if entry.DryRun && !filter.IncludeDryRun {
+		return false
+	}
+	if filter.Command != "" && entry.Command != filter.Command {
+		return false
+	}
+	if filter.Context != "" && entry.Context != filter.Context {
+		return false
+	}
+	if filter.Status != "" && entry.Status != filter.Status {
+		return false
+	}
+	if filter.Repository != "" {
+		if _, ok := entry.Repositories[filter.Repository]; !ok {
+			return false
+		}
+	}
+	if filter.Branch != "" {
+		found := false
+		for id, repository := range entry.Repositories {
+			if repository.Branch == filter.Branch && (filter.Repository == "" || id == filter.Repository) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Find returns entry by id, Latest or unique id prefix
+func Find(entries []Entry, id string) (Entry, error) {
+	if id == Latest {
+		for i := len(entries) - 1; i >= 0; i-- {
+			if !entries[i].DryRun {
+				return entries[i], nil
+			}
+		}
+		return Entry{}, errors.New("history has no runs")
+	}
+	var found []Entry
+	for _, entry := range entries {
+		if entry.ID == id {
+			return entry, nil
+		}
+		if len(id) < len(entry.ID) && entry.ID[:len(id)] == id {
+			found = append(found, entry)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return Entry{}, fmt.Errorf("run %v not found in history", id)
+	case 1:
+		return found[0], nil
+	default:
+		return Entry{}, fmt.Errorf("run %v is ambiguous, %v runs match", id, len(found))
+	}
+}
+
+// Change is difference of repository between two runs, From or To is nil when repository is missing in run
+type Change struct {
+	Repository string
+	From       *Repository
+	To         *Repository
+}
+
+// Diff returns repositories whose state differs between runs, sorted by repository id
+func Diff(from, to Entry) []Change {
+	ids := make(map[string]struct{})
+	for id := range from.Repositories {
+		ids[id] = struct{}{}
+	}
+	for id := range to.Repositories {
+		ids[id] = struct{}{}
+	}
+	var result []Change
+	for id := range ids {
+		fromRepository, fromOK := from.Repositories[id]
+		toRepository, toOK := to.Repositories[id]
+		if fromOK && toOK && fromRepository == toRepository {
+			continue
+		}
+		change := Change{Repository: id}
+		if fromOK {
+			change.From = &fromRepository
+		}
+		if toOK {
+			change.To = &toRepository
+		}
+		result = append(result, change)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Repository < result[j].Repository
+	})
+	return result
+}
@@ -0,0 +1,57 @@
+package history
+
+import (
+	"path"
+	"testing"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+func TestAppendLoadDiff(t *testing.T) {
+	file := path.Join(t.TempDir(), ".platform", "history.jsonl")
+
+	recorder := NewRecorder()
+	recorder.Handle(service.Event{Type: service.EventRepositoryResolved, RepositoryID: "a", Name: "main", Commit: "c1", Hash: "h1"})
+	recorder.Handle(service.Event{Type: service.EventRepositoryResolved, RepositoryID: "b", Name: "main", Commit: "c2", Hash: "h2"})
+	err := Append(file, recorder.Complete(Entry{ID: "1", Command: "build", Context: "default", Status: StatusSuccess}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder = NewRecorder()
+	recorder.Handle(service.Event{Type: service.EventRepositoryResolved, RepositoryID: "a", Name: "main", Commit: "c1", Hash: "h1"})
+	recorder.Handle(service.Event{Type: service.EventRepositoryResolved, RepositoryID: "b", Name: "feature", Commit: "c3", Hash: "h3"})
+	recorder.Handle(service.Event{Type: service.EventImagePushed, RepositoryID: "b", Image: "registry/b:1", Digest: "sha256:1"})
+	err = Append(file, recorder.Complete(Entry{ID: "2", Command: "build", Context: "default", Status: StatusFailed}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Append(file, Entry{ID: "3", Command: "build", Context: "default", Status: StatusSuccess, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || len(entries[1].Pushed) != 1 {
+		t.Fatalf("unexpected entries %+v", entries)
+	}
+	if !(Filter{Branch: "feature", Status: StatusFailed}).Match(entries[1]) || (Filter{Branch: "feature"}).Match(entries[0]) {
+		t.Error("unexpected match of branch filter")
+	}
+	if (Filter{}).Match(entries[2]) || !(Filter{IncludeDryRun: true}).Match(entries[2]) {
+		t.Error("unexpected match of dry-run entry")
+	}
+
+	latest, err := Find(entries, Latest)
+	if err != nil || latest.ID != "2" {
+		t.Fatalf("unexpected latest run %v: %v", latest.ID, err)
+	}
+	changes := Diff(entries[0], latest)
+	if len(changes) != 1 || changes[0].Repository != "b" || changes[0].From.Commit != "c2" || changes[0].To.Commit != "c3" {
+		t.Errorf("unexpected changes %+v", changes)
+	}
+}
@@ -1,11 +1,13 @@
 package pipeline
 
 import (
+	"bytes"
 	stdcontext "context"
 	"encoding/hex"
 	"os"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -19,12 +21,14 @@ func NewPipelineExecutor(
 	pipelines map[platform.PipelineID]string,
 	runner command.Runner,
 	repositoryProvider service.RepositoryProvider,
+	events service.EventPublisher,
 ) service.PipelineExecutor {
 	return &executor{
 		registry:           registry,
 		pipelines:          pipelines,
 		runner:             runner,
 		repositoryProvider: repositoryProvider,
+		events:             events,
 	}
 }
 
@@ -47,6 +51,7 @@ type executor struct {
 
 	runner             command.Runner
 	repositoryProvider service.RepositoryProvider
+	events             service.EventPublisher
 }
 
 func (e executor) Execute(
@@ -54,17 +59,36 @@ func (e executor) Execute(
 	contextID platform.ContextID,
 	pipeline platform.PipelineID,
 	repositoryMap map[platform.RepositoryID]service.RepositoryInfo,
-) error {
+) (err error) {
+	start := time.Now()
+	defer func() {
+		e.events.Publish(service.Event{
+			Type:     service.EventPipelineFinished,
+			Time:     time.Now(),
+			Name:     pipeline,
+			Phase:    service.PhasePipeline,
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}()
+
 	variables := e.loadPipelineVariables(contextID, pipeline, e.registry, repositoryMap)
-	pipelineFile, err := e.buildPipeline(pipeline, variables)
+	script, err := e.buildPipeline(pipeline, variables)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(pipelineFile.Name())
+	// script is passed through stdin, so rendered secrets are not written to disk
 	_, err = e.runner.Execute(ctx, command.Command{
 		Executable: "bash",
-		Args:       []string{pipelineFile.Name()},
-		Verbose:    true,
+		Args:       []string{"-s"},
+		Stdin:      script,
+		Env: map[string]string{
+			"PLATFORM_CONTEXT":  contextID,
+			"PLATFORM_PIPELINE": pipeline,
+			"PLATFORM_REGISTRY": e.registry,
+		},
+		Verbose: true,
+		Phase:   service.PhasePipeline + "-" + pipeline,
 	})
 	return err
 }
@@ -91,11 +115,9 @@ func (e executor) loadPipelineVariables(
 	}
 }
 
-func (e executor) buildPipeline(pipelineID platform.PipelineID, variables pipelineVariables) (*os.File, error) {
-	pipelineFile, err := os.CreateTemp(".platform", pipelineID)
-	if err != nil {
-		return nil, errors.Wrapf(err, "failed to create temporary file for %v pipeline", pipelineID)
-	}
+// buildPipeline renders pipeline as group command, bash reads whole group before running it,
+// so commands of pipeline reading stdin get end of input instead of rest of script
+func (e executor) buildPipeline(pipelineID platform.PipelineID, variables pipelineVariables) (*bytes.Buffer, error) {
 	pipelineTemplate, err := os.ReadFile(e.pipelines[pipelineID])
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to read %v pipeline template file", e.pipelines[pipelineID])
@@ -104,11 +126,13 @@ func (e executor) buildPipeline(pipelineID platform.PipelineID, variables pipeli
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to parse %v pipeline template", pipelineID)
 	}
-	err = pipeline.Execute(pipelineFile, variables)
+	script := bytes.NewBufferString("{\n")
+	err = pipeline.Execute(script, variables)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to execute %v pipeline template", pipelineID)
 	}
-	return pipelineFile, nil
+	script.WriteString("\n}\n")
+	return script, nil
 }
 
 var templateFunctions = template.FuncMap{
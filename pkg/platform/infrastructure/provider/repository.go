@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -13,19 +14,40 @@ import (
 	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
 )
 
+// defaultPolicies of network git commands, can be overridden in platform config
+var defaultPolicies = map[string]command.Policy{
+	platform.CommandClone: {Timeout: 30 * time.Minute, Retries: 3, Backoff: 5 * time.Second},
+	platform.CommandFetch: {Timeout: 10 * time.Minute, Retries: 3, Backoff: 5 * time.Second},
+	platform.CommandPush:  {Timeout: 10 * time.Minute, Retries: 2, Backoff: 5 * time.Second},
+}
+
 func NewRepositoryProvider(
 	repoDir string,
 	runner command.Runner,
+	policies map[string]platform.CommandPolicy,
+	repositories []platform.Repository,
 ) service.RepositoryProvider {
+	result := make(map[string]command.Policy, len(defaultPolicies))
+	for name, policy := range defaultPolicies {
+		result[name] = policy.Override(policies[name])
+	}
+	env := make(map[platform.RepositoryID]map[string]string, len(repositories))
+	for _, repository := range repositories {
+		env[repository.ID] = repository.Env
+	}
 	return &repositoryProvider{
-		repoDir: repoDir,
-		runner:  runner,
+		repoDir:  repoDir,
+		runner:   runner,
+		policies: result,
+		env:      env,
 	}
 }
 
 type repositoryProvider struct {
-	repoDir string
-	runner  command.Runner
+	repoDir  string
+	runner   command.Runner
+	policies map[string]command.Policy
+	env      map[platform.RepositoryID]map[string]string
 }
 
 func (provider repositoryProvider) Exist(repository platform.Repository) (bool, error) {
@@ -40,10 +62,18 @@ func (provider repositoryProvider) Exist(repository platform.Repository) (bool,
 }
 
 func (provider repositoryProvider) Clone(ctx context.Context, repository platform.Repository) error {
-	_, err := provider.runner.Execute(ctx, command.Command{
+	repositoryPath := provider.RepositoryPath(repository.ID)
+	_, err := provider.runner.Execute(ctx, provider.policies[platform.CommandClone].Apply(command.Command{
 		Executable: "git",
-		Args:       []string{"clone", repository.GitSrc, provider.RepositoryPath(repository.ID)},
-	})
+		Args:       []string{"clone", repository.GitSrc, repositoryPath},
+		Env:        provider.env[repository.ID],
+		Repository: repository.ID,
+		Phase:      service.PhaseClone,
+		// killed clone leaves partial directory, so next attempt would fail on existing destination
+		BeforeAttempt: func() error {
+			return errors.Wrapf(os.RemoveAll(repositoryPath), "failed to remove partial clone %v", repositoryPath)
+		},
+	}))
 	return errors.Wrapf(err, "failed to clone repository %v", repository.ID)
 }
 
@@ -55,16 +85,22 @@ func (provider repositoryProvider) Checkout(ctx context.Context, repository plat
 		WorkDir:    provider.RepositoryPath(repository.ID),
 		Executable: "git",
 		Args:       []string{"checkout", "-B", branch, fmt.Sprintf("origin/%v", branch)},
+		Env:        provider.env[repository.ID],
+		Repository: repository.ID,
+		Phase:      service.PhaseCheckout,
 	})
 	return errors.Wrapf(err, "failed to checkout repository %v on branch %v", repository.ID, branch)
 }
 
 func (provider repositoryProvider) Fetch(ctx context.Context, repository platform.Repository) error {
-	_, err := provider.runner.Execute(ctx, command.Command{
+	_, err := provider.runner.Execute(ctx, provider.policies[platform.CommandFetch].Apply(command.Command{
 		WorkDir:    provider.RepositoryPath(repository.ID),
 		Executable: "git",
 		Args:       []string{"fetch"},
-	})
+		Env:        provider.env[repository.ID],
+		Repository: repository.ID,
+		Phase:      service.PhaseFetch,
+	}))
 	return errors.Wrapf(err, "failed to fetch repository %v", repository.ID)
 }
 
@@ -73,21 +109,42 @@ func (provider repositoryProvider) RepositoryPath(id platform.RepositoryID) stri
 }
 
 func (provider repositoryProvider) Hash(ctx context.Context, repositoryID platform.RepositoryID) (string, error) {
-	hash, err := provider.runner.Execute(ctx, command.Command{
+	result, err := provider.runner.Execute(ctx, command.Command{
 		WorkDir:    provider.RepositoryPath(repositoryID),
 		Executable: "git",
 		Args:       []string{"rev-parse", "HEAD"},
+		Env:        provider.env[repositoryID],
+		Repository: repositoryID,
+		Phase:      service.PhaseGit,
 	})
-	return strings.TrimSpace(hash), errors.Wrapf(err, "failed to get hash from repository %v", repositoryID)
+	return strings.TrimSpace(result.Stdout), errors.Wrapf(err, "failed to get hash from repository %v", repositoryID)
 }
 
 func (provider repositoryProvider) BranchName(ctx context.Context, repositoryID platform.RepositoryID) (string, error) {
-	branchName, err := provider.runner.Execute(ctx, command.Command{
+	result, err := provider.runner.Execute(ctx, command.Command{
 		WorkDir:    provider.RepositoryPath(repositoryID),
 		Executable: "git",
 		Args:       []string{"rev-parse", "--abbrev-ref", "HEAD"},
+		Env:        provider.env[repositoryID],
+		Repository: repositoryID,
+		Phase:      service.PhaseGit,
 	})
-	return strings.TrimSpace(branchName), errors.Wrapf(err, "failed to get branch name from repository %v", repositoryID)
+	return strings.TrimSpace(result.Stdout), errors.Wrapf(err, "failed to get branch name from repository %v", repositoryID)
+}
+
+func (provider repositoryProvider) Tags(ctx context.Context, repositoryID platform.RepositoryID) ([]string, error) {
+	result, err := provider.runner.Execute(ctx, command.Command{
+		WorkDir:    provider.RepositoryPath(repositoryID),
+		Executable: "git",
+		Args:       []string{"tag", "--points-at", "HEAD"},
+		Env:        provider.env[repositoryID],
+		Repository: repositoryID,
+		Phase:      service.PhaseGit,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get tags from repository %v", repositoryID)
+	}
+	return strings.Fields(result.Stdout), nil
 }
 
 func (provider repositoryProvider) Reset(ctx context.Context, repositoryID platform.RepositoryID) error {
@@ -95,6 +152,9 @@ func (provider repositoryProvider) Reset(ctx context.Context, repositoryID platf
 		WorkDir:    provider.RepositoryPath(repositoryID),
 		Executable: "git",
 		Args:       []string{"reset", "--hard"},
+		Env:        provider.env[repositoryID],
+		Repository: repositoryID,
+		Phase:      service.PhaseReset,
 	})
 	if err != nil {
 		return errors.Wrapf(err, "failed to reset repository %v", repositoryID)
@@ -103,6 +163,9 @@ func (provider repositoryProvider) Reset(ctx context.Context, repositoryID platf
 		WorkDir:    provider.RepositoryPath(repositoryID),
 		Executable: "git",
 		Args:       []string{"clean", "-dxf"},
+		Env:        provider.env[repositoryID],
+		Repository: repositoryID,
+		Phase:      service.PhaseReset,
 	})
 	if err != nil {
 		return errors.Wrapf(err, "failed to clean repository %v", repositoryID)
@@ -115,6 +178,9 @@ func (provider repositoryProvider) Merge(ctx context.Context, repositoryID platf
 		WorkDir:    provider.RepositoryPath(repositoryID),
 		Executable: "git",
 		Args:       []string{"merge", fmt.Sprintf("origin/%v", branch)},
+		Env:        provider.env[repositoryID],
+		Repository: repositoryID,
+		Phase:      service.PhaseMerge,
 	})
 	if err != nil {
 		return errors.Wrapf(err, "failed to merge branch %v from repository %v", branch, repositoryID)
@@ -122,15 +188,19 @@ func (provider repositoryProvider) Merge(ctx context.Context, repositoryID platf
 	return nil
 }
 
-func (provider repositoryProvider) Push(ctx context.Context, repositoryID platform.RepositoryID, dryRun bool) (string, error) {
+func (provider repositoryProvider) Push(ctx context.Context, repositoryID platform.RepositoryID, dryRun bool) error {
 	args := []string{"push"}
 	if dryRun {
 		args = append(args, "--dry-run")
 	}
-	output, err := provider.runner.Execute(ctx, command.Command{
+	_, err := provider.runner.Execute(ctx, provider.policies[platform.CommandPush].Apply(command.Command{
 		WorkDir:    provider.RepositoryPath(repositoryID),
 		Executable: "git",
 		Args:       args,
-	})
-	return output, errors.Wrapf(err, "failed to push repository %v", repositoryID)
+		Env:        provider.env[repositoryID],
+		Verbose:    true,
+		Repository: repositoryID,
+		Phase:      service.PhasePush,
+	}))
+	return errors.Wrapf(err, "failed to push repository %v", repositoryID)
 }
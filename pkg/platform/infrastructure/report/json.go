@@ -0,0 +1,62 @@
+package report
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+const (
+	statusPassed      = "passed"
+	statusFailed      = "failed"
+	statusSkipped     = "skipped"
+	statusInterrupted = "interrupted"
+)
+
+type jsonStep struct {
+	Repository string    `json:"repository,omitempty"`
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Start      time.Time `json:"start"`
+	Duration   float64   `json:"durationSeconds"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type jsonReport struct {
+	Passed      int        `json:"passed"`
+	Failed      int        `json:"failed"`
+	Skipped     int        `json:"skipped"`
+	Interrupted int        `json:"interrupted"`
+	Steps       []jsonStep `json:"steps"`
+}
+
+func marshalJSON(steps []service.Step) ([]byte, error) {
+	result := jsonReport{Steps: make([]jsonStep, 0, len(steps))}
+	for _, step := range steps {
+		s := jsonStep{
+			Repository: step.RepositoryID,
+			Name:       step.Name,
+			Status:     statusPassed,
+			Start:      step.Start,
+			Duration:   step.Duration.Seconds(),
+		}
+		switch {
+		case step.Skipped:
+			s.Status = statusSkipped
+			result.Skipped++
+		case interrupted(step.Err):
+			s.Status = statusInterrupted
+			s.Error = failureDetails(step.Err)
+			result.Interrupted++
+		case step.Err != nil:
+			s.Status = statusFailed
+			s.Error = failureDetails(step.Err)
+			result.Failed++
+		default:
+			result.Passed++
+		}
+		result.Steps = append(result.Steps, s)
+	}
+	return json.MarshalIndent(result, "", "  ")
+}
@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+var testStart = time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+func TestMarshalJSON(t *testing.T) {
+	longStderr := strings.Repeat("a", maxFailureLength) + "stderr tail"
+	testCases := []struct {
+		name     string
+		steps    []service.Step
+		expected jsonReport
+	}{
+		{
+			name:     "empty",
+			expected: jsonReport{Steps: []jsonStep{}},
+		},
+		{
+			name: "statuses",
+			steps: []service.Step{
+				{RepositoryID: "a", Name: "sources", Start: testStart, Duration: 1500 * time.Millisecond},
+				{RepositoryID: "a", Name: "target lint", Start: testStart, Skipped: true, Err: service.ErrTargetNotFound},
+				{RepositoryID: "b", Name: "sources", Start: testStart, Duration: time.Second, Err: errors.New("make: exit status 2: no rule")},
+				{Name: "pipeline deploy", Start: testStart, Duration: 250 * time.Millisecond, Err: fmt.Errorf("bash: %w", command.ErrInterrupted)},
+			},
+			expected: jsonReport{
+				Passed:      1,
+				Failed:      1,
+				Skipped:     1,
+				Interrupted: 1,
+				Steps: []jsonStep{
+					{Repository: "a", Name: "sources", Status: statusPassed, Start: testStart, Duration: 1.5},
+					{Repository: "a", Name: "target lint", Status: statusSkipped, Start: testStart},
+					{Repository: "b", Name: "sources", Status: statusFailed, Start: testStart, Duration: 1, Error: "make: exit status 2: no rule"},
+					{Name: "pipeline deploy", Status: statusInterrupted, Start: testStart, Duration: 0.25, Error: "bash: command interrupted"},
+				},
+			},
+		},
+		{
+			name: "stderr tail",
+			steps: []service.Step{
+				{RepositoryID: "a", Name: "sources", Start: testStart, Err: errors.New(longStderr)},
+			},
+			expected: jsonReport{
+				Failed: 1,
+				Steps: []jsonStep{
+					{Repository: "a", Name: "sources", Status: statusFailed, Start: testStart, Error: longStderr[len(longStderr)-maxFailureLength:]},
+				},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			body, err := marshalJSON(testCase.steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, err := json.MarshalIndent(testCase.expected, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != string(expected) {
+				t.Errorf("expected report\n%s\ngot\n%s", expected, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+)
+
+// suite for steps not related to repository, such as pipelines
+const platformSuite = "platform"
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Details string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+func marshalJUnit(steps []service.Step) ([]byte, error) {
+	var (
+		result     junitTestSuites
+		suiteIndex = make(map[string]int)
+		suiteTimes []float64
+		totalTime  float64
+	)
+	for _, step := range steps {
+		suiteName := step.RepositoryID
+		if suiteName == "" {
+			suiteName = platformSuite
+		}
+		i, ok := suiteIndex[suiteName]
+		if !ok {
+			i = len(result.Suites)
+			suiteIndex[suiteName] = i
+			result.Suites = append(result.Suites, junitTestSuite{
+				Name:      suiteName,
+				Timestamp: step.Start.UTC().Format("2006-01-02T15:04:05"),
+			})
+			suiteTimes = append(suiteTimes, 0)
+		}
+		suite := &result.Suites[i]
+
+		testCase := junitTestCase{
+			ClassName: suiteName,
+			Name:      step.Name,
+			Time:      formatSeconds(step.Duration.Seconds()),
+		}
+		switch {
+		case step.Skipped:
+			testCase.Skipped = &junitSkipped{}
+			if step.Err != nil {
+				testCase.Skipped.Message = step.Err.Error()
+			}
+			suite.Skipped++
+			result.Skipped++
+		case step.Err != nil:
+			message := fmt.Sprintf("%v failed", step.Name)
+			if interrupted(step.Err) {
+				message = fmt.Sprintf("%v interrupted", step.Name)
+			}
+			testCase.Failure = &junitFailure{
+				Message: message,
+				Details: failureDetails(step.Err),
+			}
+			suite.Failures++
+			result.Failures++
+		}
+		suite.Cases = append(suite.Cases, testCase)
+		suite.Tests++
+		result.Tests++
+		suiteTimes[i] += step.Duration.Seconds()
+		totalTime += step.Duration.Seconds()
+	}
+	for i := range result.Suites {
+		result.Suites[i].Time = formatSeconds(suiteTimes[i])
+	}
+	result.Time = formatSeconds(totalTime)
+
+	body, err := xml.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%.3f", seconds)
+}
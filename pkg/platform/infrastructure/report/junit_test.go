@@ -0,0 +1,113 @@
+package report
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+func TestMarshalJUnit(t *testing.T) {
+	longStderr := strings.Repeat("a", maxFailureLength) + "stderr tail"
+	timestamp := "2024-05-01T10:00:00"
+	testCases := []struct {
+		name     string
+		steps    []service.Step
+		expected junitTestSuites
+	}{
+		{
+			name:     "empty",
+			expected: junitTestSuites{Time: "0.000"},
+		},
+		{
+			name: "statuses",
+			steps: []service.Step{
+				{RepositoryID: "a", Name: "sources", Start: testStart, Duration: 1500 * time.Millisecond},
+				{RepositoryID: "a", Name: "target lint", Start: testStart, Skipped: true, Err: service.ErrTargetNotFound},
+				{RepositoryID: "b", Name: "sources", Start: testStart, Duration: time.Second, Err: errors.New("make: exit status 2: no rule")},
+				{Name: "pipeline deploy", Start: testStart, Duration: 250 * time.Millisecond, Err: fmt.Errorf("bash: %w", command.ErrInterrupted)},
+			},
+			expected: junitTestSuites{
+				Tests:    4,
+				Failures: 2,
+				Skipped:  1,
+				Time:     "2.750",
+				Suites: []junitTestSuite{
+					{
+						Name: "a", Tests: 2, Skipped: 1, Time: "1.500", Timestamp: timestamp,
+						Cases: []junitTestCase{
+							{ClassName: "a", Name: "sources", Time: "1.500"},
+							{ClassName: "a", Name: "target lint", Time: "0.000", Skipped: &junitSkipped{Message: service.ErrTargetNotFound.Error()}},
+						},
+					},
+					{
+						Name: "b", Tests: 1, Failures: 1, Time: "1.000", Timestamp: timestamp,
+						Cases: []junitTestCase{
+							{ClassName: "b", Name: "sources", Time: "1.000", Failure: &junitFailure{
+								Message: "sources failed",
+								Details: "make: exit status 2: no rule",
+							}},
+						},
+					},
+					{
+						Name: platformSuite, Tests: 1, Failures: 1, Time: "0.250", Timestamp: timestamp,
+						Cases: []junitTestCase{
+							{ClassName: platformSuite, Name: "pipeline deploy", Time: "0.250", Failure: &junitFailure{
+								Message: "pipeline deploy interrupted",
+								Details: "bash: command interrupted",
+							}},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "stderr tail",
+			steps: []service.Step{
+				{RepositoryID: "a", Name: "sources", Start: testStart, Err: errors.New(longStderr)},
+			},
+			expected: junitTestSuites{
+				Tests:    1,
+				Failures: 1,
+				Time:     "0.000",
+				Suites: []junitTestSuite{
+					{
+						Name: "a", Tests: 1, Failures: 1, Time: "0.000", Timestamp: timestamp,
+						Cases: []junitTestCase{
+							{ClassName: "a", Name: "sources", Time: "0.000", Failure: &junitFailure{
+								Message: "sources failed",
+								Details: longStderr[len(longStderr)-maxFailureLength:],
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			body, err := marshalJUnit(testCase.steps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(body), xml.Header) {
+				t.Errorf("expected xml header, got %s", body)
+			}
+			var report junitTestSuites
+			err = xml.Unmarshal(body, &report)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report.XMLName = xml.Name{}
+			if !reflect.DeepEqual(report, testCase.expected) {
+				t.Errorf("expected report %+v, got %+v", testCase.expected, report)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+const (
+	FormatJUnit = "junit"
+	FormatJSON  = "json"
+)
+
+// maxFailureLength limits failure details to tail of error message
+const maxFailureLength = 4096
+
+type Target struct {
+	Format string
+	Path   string
+}
+
+// ParseTarget parses report target in format <format>:<path>
+func ParseTarget(spec string) (Target, error) {
+	format, reportPath, ok := strings.Cut(spec, ":")
+	if !ok || reportPath == "" {
+		return Target{}, fmt.Errorf("invalid report %v, expected <format>:<path>", spec)
+	}
+	switch format {
+	case FormatJUnit, FormatJSON:
+		return Target{Format: format, Path: reportPath}, nil
+	default:
+		return Target{}, fmt.Errorf("unknown report format %v", format)
+	}
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+type Recorder struct {
+	mu    sync.Mutex
+	steps []service.Step
+}
+
+// Handle records finished steps and pipelines, recorder subscribes it to event stream
+func (recorder *Recorder) Handle(event service.Event) {
+	switch event.Type {
+	case service.EventStepFinished:
+		recorder.Record(event.Step())
+	case service.EventPipelineFinished:
+		step := event.Step()
+		step.Name = "pipeline " + event.Name
+		recorder.Record(step)
+	default:
+	}
+}
+
+func (recorder *Recorder) Record(step service.Step) {
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+	recorder.steps = append(recorder.steps, step)
+}
+
+func (recorder *Recorder) Write(target Target) error {
+	recorder.mu.Lock()
+	steps := make([]service.Step, len(recorder.steps))
+	copy(steps, recorder.steps)
+	recorder.mu.Unlock()
+
+	var (
+		body []byte
+		err  error
+	)
+	switch target.Format {
+	case FormatJUnit:
+		body, err = marshalJUnit(steps)
+	case FormatJSON:
+		body, err = marshalJSON(steps)
+	default:
+		err = fmt.Errorf("unknown report format %v", target.Format)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to build %v report", target.Format)
+	}
+	err = os.MkdirAll(path.Dir(target.Path), 0o755)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create directory for report %v", target.Path)
+	}
+	err = os.WriteFile(target.Path, body, 0o600)
+	return errors.Wrapf(err, "failed to write report %v", target.Path)
+}
+
+// interrupted reports whether step failed because its command was interrupted by cancellation
+func interrupted(err error) bool {
+	return errors.Is(err, command.ErrInterrupted)
+}
+
+func failureDetails(err error) string {
+	details := err.Error()
+	if len(details) > maxFailureLength {
+		details = details[len(details)-maxFailureLength:]
+	}
+	return details
+}
@@ -0,0 +1,48 @@
+package shell
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+
+	"github.com/tss-calculator/tools/pkg/platform/application/model/platform"
+	"github.com/tss-calculator/tools/pkg/platform/application/service"
+	"github.com/tss-calculator/tools/pkg/platform/infrastructure/command"
+)
+
+func NewCommandExecutor(
+	runner command.Runner,
+	repositoryProvider service.RepositoryProvider,
+) service.CommandExecutor {
+	return &executor{
+		runner:             runner,
+		repositoryProvider: repositoryProvider,
+	}
+}
+
+type executor struct {
+	runner             command.Runner
+	repositoryProvider service.RepositoryProvider
+}
+
+func (e executor) Execute(
+	ctx context.Context,
+	repositoryID platform.RepositoryID,
+	executable string,
+	args []string,
+) (int, error) {
+	result, err := e.runner.Execute(ctx, command.Command{
+		WorkDir:    e.repositoryProvider.RepositoryPath(repositoryID),
+		Executable: executable,
+		Args:       args,
+		Verbose:    true,
+		Console:    true,
+		Repository: repositoryID,
+		Phase:      service.PhaseExec,
+	})
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return result.ExitCode, nil
+	}
+	return result.ExitCode, err
+}